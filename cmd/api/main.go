@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -14,6 +20,9 @@ import (
 func main() {
 	_ = godotenv.Load()
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// 1. Load config
 	cfg := config.Load()
 
@@ -24,10 +33,34 @@ func main() {
 	}
 	defer db.Close()
 
-	// 3. Create router
-	router := transport.NewRouter(db, cfg)
+	// 3. Create router (loads active incidents into the spatial index,
+	//    starts background workers)
+	var workers sync.WaitGroup
+
+	router, err := transport.NewRouter(ctx, db, cfg, &workers)
+	if err != nil {
+		log.Fatal("router init failed:", err)
+	}
 
 	// 4. Start server
-	log.Println("Server started on port", cfg.AppPort)
-	log.Fatal(http.ListenAndServe(":"+cfg.AppPort, router))
+	server := &http.Server{Addr: ":" + cfg.AppPort, Handler: router}
+
+	go func() {
+		log.Println("Server started on port", cfg.AppPort)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// 5. Graceful shutdown: finish HTTP requests, then wait for
+	//    in-flight webhook deliveries before closing the DB
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_ = server.Shutdown(shutdownCtx)
+	workers.Wait()
+
+	log.Println("Server stopped")
 }
@@ -2,21 +2,109 @@ package main
 
 import (
 	"encoding/json"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/pkg/webhook"
 )
 
+// Допустимое расхождение timestamp подписи с текущим временем.
+const signatureTolerance = 5 * time.Minute
+
+// replayGuard — идемпотентность по delivery ID: доставка обрабатывается
+// один раз, повторы (перехваченный запрос или повтор после потерянного
+// ответа) подтверждаются без обработки. ID хранится до timestamp+tolerance:
+// после этого запрос с тем же timestamp отсекается проверкой подписи, а
+// timestamp из будущего дальше чем на tolerance не принимается вовсе.
+type replayGuard struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// accept возвращает false, если доставка deliveryID уже принималась.
+func (g *replayGuard) accept(deliveryID string, ts, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for id, t := range g.expires {
+		if now.After(t) {
+			delete(g.expires, id)
+		}
+	}
+
+	expires, seen := g.expires[deliveryID]
+	// Повтор с более поздним timestamp продлевает хранение ID: его подпись
+	// действительна дольше
+	if until := ts.Add(signatureTolerance); until.After(expires) {
+		g.expires[deliveryID] = until
+	}
+	return !seen
+}
+
 func main() {
+	secret := os.Getenv("WEBHOOK_SECRET")
+	guard := &replayGuard{expires: make(map[string]time.Time)}
+
 	http.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		deliveryID := r.Header.Get(webhook.HeaderDeliveryID)
+		timestamp := r.Header.Get(webhook.HeaderTimestamp)
+
+		if secret != "" {
+			now := time.Now()
+
+			err := webhook.Verify(
+				secret,
+				r.Header.Get(webhook.HeaderSignature),
+				timestamp,
+				body,
+				signatureTolerance,
+				now,
+			)
+			if err != nil {
+				log.Printf("WEBHOOK REJECTED (%s): %v\n", deliveryID, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			if deliveryID == "" {
+				log.Println("WEBHOOK REJECTED: missing delivery ID")
+				http.Error(w, "missing delivery ID", http.StatusBadRequest)
+				return
+			}
+
+			// Verify уже проверил формат timestamp и окно ±signatureTolerance
+			unix, _ := strconv.ParseInt(timestamp, 10, 64)
+			if !guard.accept(deliveryID, time.Unix(unix, 0), now) {
+				log.Printf("WEBHOOK DUPLICATE (%s): already received, ignored\n", deliveryID)
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+
 		var payload map[string]interface{}
-		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_ = json.Unmarshal(body, &payload)
 
-		log.Println("WEBHOOK RECEIVED:")
+		log.Printf("WEBHOOK RECEIVED (%s):\n", deliveryID)
 		log.Printf("%+v\n", payload)
 
 		w.WriteHeader(http.StatusOK)
 	})
 
+	if secret == "" {
+		log.Println("WEBHOOK_SECRET is not set, signatures are not verified")
+	}
+
 	log.Println("Webhook mock listening on :9090")
 	log.Fatal(http.ListenAndServe(":9090", nil))
 }
@@ -4,28 +4,115 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	AppPort                string
-	PostgresDSN            string
-	APIKey                 string
-	StatsTimeWindowMinutes int
-	WebhookURL             string
+	AppPort                  string
+	PostgresDSN              string
+	APIKey                   string
+	APIKeys                  map[string]string
+	APIAdmins                map[string]bool
+	StatsTimeWindowMinutes   int
+	WebhookURL               string
+	WebhookSecret            string
+	WebhookWorkers           int
+	WebhookMaxAttempts       int
+	WebhookRetryBaseSeconds  int
+	WebhookRetryMaxSeconds   int
+	AlertMode                string
+	DwellMinutes             int
+	AlertCooldownSeconds     int
+	AlertCooldownStore       string
+	BroadcastWindowMinutes   int
+	ScheduleIntervalSeconds  int
+	ApproachBufferM          int
+	PredictionHorizonSeconds int
+	DistanceMethod           string
+	IncidentStore            string
 }
 
+// DefaultAPIKeyIdentity — идентификатор ключа из API_KEY в истории изменений.
+const DefaultAPIKeyIdentity = "default"
+
+const (
+	// AlertModeTransitions — вебхуки только на вход/выход/нахождение в зоне.
+	AlertModeTransitions = "transitions"
+	// AlertModeEveryCheck — вебхук на каждую проверку внутри зоны.
+	AlertModeEveryCheck = "every_check"
+)
+
+const (
+	// DistanceHaversine — сферическая Земля (быстро, погрешность до ~0.5%).
+	DistanceHaversine = "haversine"
+	// DistanceVincenty — эллипсоид WGS-84 (формула Винсенти).
+	DistanceVincenty = "vincenty"
+)
+
+const (
+	// IncidentStorePostgres — инциденты в PostgreSQL без расширений.
+	IncidentStorePostgres = "postgres"
+	// IncidentStorePostGIS — то же, плюс поиск зон по точке через PostGIS
+	// (нужны migrations/postgis).
+	IncidentStorePostGIS = "postgis"
+)
+
+const (
+	// CooldownStorePostgres — кулдаун общий для всех реплик.
+	CooldownStorePostgres = "postgres"
+	// CooldownStoreMemory — кулдаун в памяти, для одного экземпляра.
+	CooldownStoreMemory = "memory"
+	// CooldownStoreNone — без кулдауна.
+	CooldownStoreNone = "none"
+)
+
 func Load() *Config {
 	appPort := getEnv("APP_PORT", "8080")
 	postgresDSN := getEnv("POSTGRES_DSN", "")
 	apiKey := getEnv("API_KEY", "secret123")
+	namedAPIKeys := getEnv("API_KEYS", "")
+	apiAdminsStr := getEnv("API_ADMINS", "")
 	statsMinutesStr := getEnv("STATS_TIME_WINDOW_MINUTES", "5")
 	webhookURL := getEnv("WEBHOOK_URL", "")
+	webhookSecret := getEnv("WEBHOOK_SECRET", "")
+	webhookWorkers := getEnvInt("WEBHOOK_WORKERS", 8)
+	webhookMaxAttempts := getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10)
+	webhookRetryBase := getEnvInt("WEBHOOK_RETRY_BASE_SECONDS", 2)
+	webhookRetryMax := getEnvInt("WEBHOOK_RETRY_MAX_SECONDS", 3600)
+	alertMode := getEnv("ALERT_MODE", AlertModeTransitions)
+	dwellMinutes := getEnvInt("DWELL_MINUTES", 10)
+	alertCooldownSeconds := getEnvInt("ALERT_COOLDOWN_SECONDS", 300)
+	alertCooldownStore := getEnv("ALERT_COOLDOWN_STORE", CooldownStorePostgres)
+	broadcastWindowStr := getEnv("BROADCAST_WINDOW_MINUTES", "5")
+	scheduleIntervalSeconds := getEnvInt("SCHEDULE_INTERVAL_SECONDS", 30)
+	approachBufferStr := getEnv("APPROACH_BUFFER_M", "200")
+	predictionHorizonStr := getEnv("PREDICTION_HORIZON_SECONDS", "120")
+	distanceMethod := getEnv("DISTANCE_METHOD", DistanceHaversine)
+	incidentStore := getEnv("INCIDENT_STORE", IncidentStorePostgres)
 
 	statsMinutes, err := strconv.Atoi(statsMinutesStr)
 	if err != nil {
 		log.Fatal("invalid STATS_TIME_WINDOW_MINUTES")
 	}
 
+	// 0 отключает предупреждение о приближении для зон без своего буфера
+	approachBufferM, err := strconv.Atoi(approachBufferStr)
+	if err != nil || approachBufferM < 0 {
+		log.Fatal("invalid APPROACH_BUFFER_M")
+	}
+
+	// 0 отключает уведомление о новой зоне без повторной проверки
+	broadcastWindowMinutes, err := strconv.Atoi(broadcastWindowStr)
+	if err != nil || broadcastWindowMinutes < 0 {
+		log.Fatal("invalid BROADCAST_WINDOW_MINUTES")
+	}
+
+	// 0 отключает прогноз по скорости и курсу
+	predictionHorizonSeconds, err := strconv.Atoi(predictionHorizonStr)
+	if err != nil || predictionHorizonSeconds < 0 {
+		log.Fatal("invalid PREDICTION_HORIZON_SECONDS")
+	}
+
 	if postgresDSN == "" {
 		log.Fatal("POSTGRES_DSN is required")
 	}
@@ -34,12 +121,71 @@ func Load() *Config {
 		log.Fatal("API_KEY is required")
 	}
 
+	apiKeys := map[string]string{apiKey: DefaultAPIKeyIdentity}
+	for _, entry := range strings.Split(namedAPIKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || key == "" {
+			log.Fatal("invalid API_KEYS: expected name:key[,name:key]")
+		}
+		if _, dup := apiKeys[key]; dup {
+			log.Fatalf("duplicate key in API_KEYS for %s", name)
+		}
+		apiKeys[key] = name
+	}
+
+	// Администраторы — идентификаторы ключей; по умолчанию их нет
+	apiAdmins := make(map[string]bool)
+	for _, name := range strings.Split(apiAdminsStr, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			apiAdmins[name] = true
+		}
+	}
+
+	if alertMode != AlertModeTransitions && alertMode != AlertModeEveryCheck {
+		log.Fatal("invalid ALERT_MODE")
+	}
+
+	if distanceMethod != DistanceHaversine && distanceMethod != DistanceVincenty {
+		log.Fatal("invalid DISTANCE_METHOD")
+	}
+
+	if incidentStore != IncidentStorePostgres && incidentStore != IncidentStorePostGIS {
+		log.Fatal("invalid INCIDENT_STORE")
+	}
+
+	switch alertCooldownStore {
+	case CooldownStorePostgres, CooldownStoreMemory, CooldownStoreNone:
+	default:
+		log.Fatal("invalid ALERT_COOLDOWN_STORE")
+	}
+
 	return &Config{
-		AppPort:                appPort,
-		PostgresDSN:            postgresDSN,
-		APIKey:                 apiKey,
-		StatsTimeWindowMinutes: statsMinutes,
-		WebhookURL:             webhookURL,
+		AppPort:                  appPort,
+		PostgresDSN:              postgresDSN,
+		APIKey:                   apiKey,
+		APIKeys:                  apiKeys,
+		APIAdmins:                apiAdmins,
+		StatsTimeWindowMinutes:   statsMinutes,
+		WebhookURL:               webhookURL,
+		WebhookSecret:            webhookSecret,
+		WebhookWorkers:           webhookWorkers,
+		WebhookMaxAttempts:       webhookMaxAttempts,
+		WebhookRetryBaseSeconds:  webhookRetryBase,
+		WebhookRetryMaxSeconds:   webhookRetryMax,
+		AlertMode:                alertMode,
+		DwellMinutes:             dwellMinutes,
+		AlertCooldownSeconds:     alertCooldownSeconds,
+		AlertCooldownStore:       alertCooldownStore,
+		BroadcastWindowMinutes:   broadcastWindowMinutes,
+		ScheduleIntervalSeconds:  scheduleIntervalSeconds,
+		ApproachBufferM:          approachBufferM,
+		PredictionHorizonSeconds: predictionHorizonSeconds,
+		DistanceMethod:           distanceMethod,
+		IncidentStore:            incidentStore,
 	}
 }
 
@@ -49,3 +195,16 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Fatalf("invalid %s", key)
+	}
+	return n
+}
@@ -0,0 +1,31 @@
+package domain
+
+const (
+	CategoryFire     = "fire"
+	CategoryFlood    = "flood"
+	CategoryPolice   = "police"
+	CategoryRoad     = "road"
+	CategoryWeather  = "weather"
+	CategoryMedical  = "medical"
+	CategoryChemical = "chemical"
+	CategoryOther    = "other"
+)
+
+// DefaultCategory — категория инцидента, если она не указана при создании.
+const DefaultCategory = CategoryOther
+
+func IsKnownCategory(category string) bool {
+	switch category {
+	case CategoryFire,
+		CategoryFlood,
+		CategoryPolice,
+		CategoryRoad,
+		CategoryWeather,
+		CategoryMedical,
+		CategoryChemical,
+		CategoryOther:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,31 @@
+package domain
+
+// Типы событий, на которые подписываются получатели вебхуков.
+const (
+	// EventAlert — пользователь находится внутри активной зоны
+	// (отправляется на каждую проверку, если отслеживание переходов выключено).
+	EventAlert = "alert"
+
+	// Переходы: пользователь вошёл в зону, вышел из неё или
+	// находится в ней дольше заданного времени.
+	EventEntered = "incident.entered"
+	EventExited  = "incident.exited"
+	EventDwelled = "incident.dwelled"
+
+	// EventApproaching — пользователь оказался в буфере предупреждения
+	// вокруг зоны, но ещё не внутри неё.
+	EventApproaching = "incident.approaching"
+
+	// EventExpired — у зоны истёк срок действия (expires_at), она
+	// деактивирована автоматически. Отправляется без user_id.
+	EventExpired = "incident.expired"
+)
+
+func IsKnownEventType(eventType string) bool {
+	switch eventType {
+	case EventAlert, EventEntered, EventExited, EventDwelled, EventExpired, EventApproaching:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	GeometryPolygon      = "Polygon"
+	GeometryMultiPolygon = "MultiPolygon"
+	GeometryLineString   = "LineString"
+)
+
+// Point — позиция в порядке GeoJSON: [lon, lat].
+type Point [2]float64
+
+func (p Point) Lon() float64 { return p[0] }
+func (p Point) Lat() float64 { return p[1] }
+
+// Ring — замкнутый контур (первая точка совпадает с последней).
+type Ring []Point
+
+// Polygon — внешний контур и, опционально, дыры.
+type Polygon []Ring
+
+// LineString — ломаная: участок дороги, берег реки или маршрут.
+type LineString []Point
+
+// Geometry — форма зоны инцидента, сериализуется как GeoJSON geometry.
+// Polygons заполняется для Polygon/MultiPolygon, Line — для LineString.
+type Geometry struct {
+	Type     string
+	Polygons []Polygon
+	Line     LineString
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	var coords interface{}
+
+	switch g.Type {
+	case GeometryPolygon:
+		if len(g.Polygons) != 1 {
+			return nil, errors.New("polygon geometry must contain exactly one polygon")
+		}
+		coords = g.Polygons[0]
+	case GeometryMultiPolygon:
+		coords = g.Polygons
+	case GeometryLineString:
+		coords = g.Line
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+
+	raw, err := json.Marshal(coords)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(geoJSONGeometry{Type: g.Type, Coordinates: raw})
+}
+
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var raw geoJSONGeometry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch raw.Type {
+	case GeometryPolygon:
+		var p Polygon
+		if err := json.Unmarshal(raw.Coordinates, &p); err != nil {
+			return err
+		}
+		g.Polygons = []Polygon{p}
+	case GeometryMultiPolygon:
+		var ps []Polygon
+		if err := json.Unmarshal(raw.Coordinates, &ps); err != nil {
+			return err
+		}
+		g.Polygons = ps
+	case GeometryLineString:
+		var l LineString
+		if err := json.Unmarshal(raw.Coordinates, &l); err != nil {
+			return err
+		}
+		g.Line = l
+	default:
+		return fmt.Errorf("unsupported geometry type %q", raw.Type)
+	}
+
+	g.Type = raw.Type
+	return nil
+}
+
+func (g Geometry) Validate() error {
+	switch g.Type {
+	case GeometryPolygon:
+		if len(g.Polygons) != 1 {
+			return errors.New("polygon geometry must contain exactly one polygon")
+		}
+	case GeometryMultiPolygon:
+		if len(g.Polygons) == 0 {
+			return errors.New("multipolygon geometry must contain at least one polygon")
+		}
+	case GeometryLineString:
+		return g.Line.Validate()
+	default:
+		return fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+
+	for _, p := range g.Polygons {
+		if len(p) == 0 {
+			return errors.New("polygon must have an outer ring")
+		}
+		for _, r := range p {
+			if err := r.validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l LineString) Validate() error {
+	if len(l) < 2 {
+		return errors.New("line must have at least 2 positions")
+	}
+	for _, pt := range l {
+		if pt.Lat() < -90 || pt.Lat() > 90 || pt.Lon() < -180 || pt.Lon() > 180 {
+			return fmt.Errorf("position %v is out of range", pt)
+		}
+	}
+	return nil
+}
+
+func (r Ring) validate() error {
+	if len(r) < 4 {
+		return errors.New("ring must have at least 4 positions")
+	}
+	if r[0] != r[len(r)-1] {
+		return errors.New("ring must be closed")
+	}
+	for _, pt := range r {
+		if pt.Lat() < -90 || pt.Lat() > 90 || pt.Lon() < -180 || pt.Lon() > 180 {
+			return fmt.Errorf("position %v is out of range", pt)
+		}
+	}
+	return nil
+}
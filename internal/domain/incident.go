@@ -2,12 +2,95 @@ package domain
 
 import "time"
 
+// Incident — опасная зона. Если Geometry задана, зона — полигон или
+// мультиполигон, а Lat/Lon/RadiusM описывают окружность вокруг него.
+// StartsAt/ExpiresAt (nil — без ограничения) задают окно действия зоны.
+// UpdatedBy — идентификатор API-ключа автора последнего изменения.
+// ApproachBufferM — ширина буфера предупреждения «приближение» вокруг зоны;
+// nil — значение по умолчанию из конфигурации, 0 — без предупреждения.
+// LineBufferM — для линейной зоны (Geometry LineString) расстояние от линии
+// в обе стороны, в пределах которого точка считается внутри зоны.
 type Incident struct {
+	ID              int64
+	Title           string
+	Severity        string
+	Category        string
+	Lat             float64
+	Lon             float64
+	RadiusM         int
+	Geometry        *Geometry
+	LineBufferM     int
+	ApproachBufferM *int
+	Active          bool
+	StartsAt        *time.Time
+	ExpiresAt       *time.Time
+	CreatedAt       time.Time
+	UpdatedBy       string
+	UpdatedAt       time.Time
+}
+
+// ActiveAt сообщает, действует ли зона в момент at: она не деактивирована
+// и at попадает в окно [StartsAt, ExpiresAt).
+func (i Incident) ActiveAt(at time.Time) bool {
+	if !i.Active {
+		return false
+	}
+	if i.StartsAt != nil && at.Before(*i.StartsAt) {
+		return false
+	}
+	if i.ExpiresAt != nil && !at.Before(*i.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// IncidentFilter — фильтр списка инцидентов; пустые поля не ограничивают
+// выборку. BBox отбирает зоны, описанная окружность которых пересекает
+// прямоугольник.
+type IncidentFilter struct {
+	Active      *bool
+	Severity    string
+	Category    string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	BBox        *BoundingBox
+}
+
+// Поля сортировки списка инцидентов.
+const (
+	IncidentSortID        = "id"
+	IncidentSortCreatedAt = "created_at"
+	IncidentSortRadius    = "radius_m"
+)
+
+func IsKnownIncidentSort(sort string) bool {
+	switch sort {
+	case IncidentSortID, IncidentSortCreatedAt, IncidentSortRadius:
+		return true
+	default:
+		return false
+	}
+}
+
+// IncidentCursor — позиция последнего элемента страницы для keyset-пагинации:
+// значение поля сортировки и ID (для однозначного порядка).
+type IncidentCursor struct {
 	ID        int64
-	Title     string
-	Lat       float64
-	Lon       float64
-	RadiusM   int
-	Active    bool
 	CreatedAt time.Time
+	RadiusM   int
+}
+
+// IncidentPage — сортировка и размер страницы. After задаёт продолжение
+// после курсора; без него используется Offset.
+type IncidentPage struct {
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+	After  *IncidentCursor
+}
+
+// CursorOf возвращает курсор, указывающий на инцидент.
+func CursorOf(i Incident) IncidentCursor {
+	return IncidentCursor{ID: i.ID, CreatedAt: i.CreatedAt, RadiusM: i.RadiusM}
 }
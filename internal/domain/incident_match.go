@@ -0,0 +1,24 @@
+package domain
+
+// Положение точки относительно зоны.
+const (
+	MatchInside      = "inside"
+	MatchApproaching = "approaching"
+	MatchPredicted   = "predicted"
+)
+
+// IncidentMatch — зона в результате проверки координат. Approaching —
+// точка вне зоны, но ближе буфера предупреждения; DistanceM — расстояние
+// до границы зоны (0 внутри). Predicted — зона дальше буфера, но на
+// прогнозируемом пути пользователя; тогда DistanceM — путь до входа в неё.
+// ETASeconds — прогнозируемое время до входа в зону (nil — не прогнозируется).
+type IncidentMatch struct {
+	Incident
+	Status     string
+	DistanceM  float64
+	ETASeconds *float64
+}
+
+func (m IncidentMatch) Inside() bool {
+	return m.Status == MatchInside
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Операции, которые фиксируются в истории инцидента.
+const (
+	VersionOpCreate     = "create"
+	VersionOpUpdate     = "update"
+	VersionOpDeactivate = "deactivate"
+	VersionOpActivate   = "activate"
+	VersionOpDelete     = "delete"
+)
+
+// ActorSystem — автор изменений, сделанных самим сервисом (например, истечение срока).
+const ActorSystem = "system"
+
+// IncidentVersion — неизменяемая запись истории: состояние инцидента после
+// изменения (для удаления — до него) и разница с предыдущей версией в виде
+// {"поле": {"from": ..., "to": ...}}.
+type IncidentVersion struct {
+	ID         int64
+	IncidentID int64
+	Version    int
+	Op         string
+	Actor      string
+	ChangedAt  time.Time
+	Incident   Incident
+	Diff       json.RawMessage
+}
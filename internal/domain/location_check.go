@@ -2,16 +2,39 @@ package domain
 
 import "time"
 
+// LocationCheck — сохранённая проверка. IncidentIDs, HasDanger и DistanceM
+// относятся только к зонам, внутри которых была точка; Matches — все зоны
+// из ответа.
 type LocationCheck struct {
-	ID           int64
-	UserID       string
-	Lat          float64
-	Lon          float64
+	ID     int64
+	UserID string
+	Lat    float64
+	Lon    float64
 
-	IncidentIDs  []int64    
-	HasDanger    bool       
-	DistanceM    int        
+	IncidentIDs []int64
+	HasDanger   bool
+	DistanceM   int
+	Matches     []LocationCheckMatch
 
-	CheckedAt    time.Time
+	CheckedAt time.Time
 }
 
+// LocationCheckMatch — зона в результате проверки: положение точки
+// относительно неё (MatchInside, ...) и расстояние до её границы в метрах
+// (0 внутри; для MatchPredicted — путь до входа по прогнозу).
+type LocationCheckMatch struct {
+	IncidentID int64
+	Status     string
+	DistanceM  int
+}
+
+// LocationCheckFilter — выборка истории проверок; нулевые поля не фильтруют.
+type LocationCheckFilter struct {
+	UserID     string
+	IncidentID int64
+	HasDanger  *bool
+	From       time.Time
+	To         time.Time
+	Offset     int
+	Limit      int
+}
@@ -0,0 +1,10 @@
+package domain
+
+// NearbyIncident — зона рядом с точкой: расстояние до её границы
+// (0, если точка внутри) и азимут на ближайшую точку зоны.
+type NearbyIncident struct {
+	Incident   Incident
+	DistanceM  float64
+	BearingDeg float64
+	Inside     bool
+}
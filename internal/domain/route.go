@@ -0,0 +1,22 @@
+package domain
+
+// RoutePoint — точка маршрута и длина пути до неё от начала маршрута.
+type RoutePoint struct {
+	Lat       float64
+	Lon       float64
+	DistanceM float64
+}
+
+// RouteSection — участок маршрута внутри зоны. Если маршрут начинается
+// (заканчивается) внутри зоны, Entry (Exit) — его начало (конец).
+type RouteSection struct {
+	Entry RoutePoint
+	Exit  RoutePoint
+}
+
+// RouteCrossing — зона, которую пересекает маршрут, и участки маршрута
+// внутри неё в порядке движения.
+type RouteCrossing struct {
+	Incident Incident
+	Sections []RouteSection
+}
@@ -0,0 +1,25 @@
+package domain
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// DefaultSeverity — уровень инцидента, если он не указан при создании.
+const DefaultSeverity = SeverityWarning
+
+// SeverityRank возвращает порядок уровня (чем больше, тем опаснее);
+// 0 — неизвестный уровень.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+type BoundingBox struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Subscription — получатель вебхуков со своими фильтрами.
+// Пустые EventTypes/IncidentIDs, nil BBox и пустой MinSeverity — без фильтра.
+type Subscription struct {
+	ID          int64
+	URL         string
+	Secret      string
+	EventTypes  []string
+	IncidentIDs []int64
+	BBox        *BoundingBox
+	MinSeverity string
+	Active      bool
+	CreatedAt   time.Time
+}
+
+func (s Subscription) WantsEvent(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WantsIncident проверяет фильтры по ID, по попаданию центра зоны в BBox
+// и по минимальному уровню опасности.
+func (s Subscription) WantsIncident(i Incident) bool {
+	if len(s.IncidentIDs) > 0 {
+		found := false
+		for _, id := range s.IncidentIDs {
+			if id == i.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if s.BBox != nil && !s.BBox.Contains(i.Lat, i.Lon) {
+		return false
+	}
+
+	if s.MinSeverity != "" && SeverityRank(i.Severity) < SeverityRank(s.MinSeverity) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,9 @@
+package domain
+
+// UserPosition — последняя позиция пользователя, о которой нужно сообщить
+// потокам событий на других репликах.
+type UserPosition struct {
+	UserID string
+	Lat    float64
+	Lon    float64
+}
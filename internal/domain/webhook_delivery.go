@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery — запись outbox: вебхук, ожидающий (повторной) отправки.
+type WebhookDelivery struct {
+	ID             int64
+	UID            string
+	SubscriptionID *int64
+	URL            string
+	// Secret подписчика, подставляется при выборке; в outbox не хранится.
+	Secret         string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+type WebhookAttempt struct {
+	DeliveryID int64
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// ZonePresence — пользователь находится внутри зоны инцидента (Inside)
+// или в буфере предупреждения рядом с ней. EnteredAt — момент перехода в
+// текущее состояние.
+type ZonePresence struct {
+	UserID        string
+	IncidentID    int64
+	EnteredAt     time.Time
+	LastSeenAt    time.Time
+	DwellNotified bool
+	Inside        bool
+}
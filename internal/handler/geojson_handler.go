@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/middleware"
+	"github.com/kassse1/geo-alert-core/internal/service"
+)
+
+/*
+=====================
+GEOJSON
+GET  /api/v1/incidents/geojson?active=true|false
+POST /api/v1/incidents/geojson
+=====================
+*/
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string            `json:"type"`
+	ID         int64             `json:"id,omitempty"`
+	Geometry   json.RawMessage   `json:"geometry"`
+	Properties featureProperties `json:"properties"`
+}
+
+type featureProperties struct {
+	ID              int64      `json:"id,omitempty"`
+	Title           string     `json:"title"`
+	Severity        string     `json:"severity,omitempty"`
+	Category        string     `json:"category,omitempty"`
+	RadiusM         int        `json:"radius_m,omitempty"`
+	Active          *bool      `json:"active,omitempty"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	CreatedAt       *string    `json:"created_at,omitempty"`
+	ApproachBufferM *int       `json:"approach_buffer_m,omitempty"`
+	LineBufferM     int        `json:"line_buffer_m,omitempty"`
+}
+
+type pointGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates domain.Point `json:"coordinates"`
+}
+
+type importFeatureResult struct {
+	Index  int    `json:"index"`
+	ID     int64  `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type importResponse struct {
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Failed  int                   `json:"failed"`
+	Results []importFeatureResult `json:"results"`
+}
+
+func (h *IncidentHandler) ExportGeoJSON(w http.ResponseWriter, r *http.Request) {
+	activeParam := r.URL.Query().Get("active")
+
+	var activeFilter *bool
+	if activeParam != "" {
+		v, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			http.Error(w, "invalid active", http.StatusBadRequest)
+			return
+		}
+		activeFilter = &v
+	}
+
+	incidents, err := h.service.Export(activeFilter != nil && *activeFilter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, i := range incidents {
+		if activeFilter != nil && i.Active != *activeFilter {
+			continue
+		}
+		f, err := incidentToFeature(i)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fc.Features = append(fc.Features, f)
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	_ = json.NewEncoder(w).Encode(fc)
+}
+
+func (h *IncidentHandler) ImportGeoJSON(w http.ResponseWriter, r *http.Request) {
+	var fc featureCollection
+
+	if err := json.NewDecoder(r.Body).Decode(&fc); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if fc.Type != "FeatureCollection" {
+		http.Error(w, "expected FeatureCollection", http.StatusBadRequest)
+		return
+	}
+
+	resp := importResponse{Results: make([]importFeatureResult, 0, len(fc.Features))}
+
+	for idx, f := range fc.Features {
+		result := importFeatureResult{Index: idx}
+
+		incident, err := featureToIncident(f)
+		if err == nil {
+			incident.UpdatedBy = middleware.Identity(r.Context())
+
+			var created bool
+			created, err = h.service.Upsert(incident)
+			if errors.Is(err, service.ErrIncidentNotFound) {
+				err = fmt.Errorf("incident %d not found: remove the id to create a new incident", incident.ID)
+			}
+			if err == nil {
+				result.ID = incident.ID
+				if created {
+					result.Status = "created"
+					resp.Created++
+				} else {
+					result.Status = "updated"
+					resp.Updated++
+				}
+			}
+		}
+
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			resp.Failed++
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func incidentToFeature(i domain.Incident) (feature, error) {
+	var (
+		geometry []byte
+		err      error
+	)
+
+	if i.Geometry != nil {
+		geometry, err = json.Marshal(i.Geometry)
+	} else {
+		geometry, err = json.Marshal(pointGeometry{
+			Type:        "Point",
+			Coordinates: domain.Point{i.Lon, i.Lat},
+		})
+	}
+	if err != nil {
+		return feature{}, err
+	}
+
+	active := i.Active
+	createdAt := i.CreatedAt.Format(time.RFC3339)
+
+	return feature{
+		Type:     "Feature",
+		ID:       i.ID,
+		Geometry: geometry,
+		Properties: featureProperties{
+			ID:              i.ID,
+			Title:           i.Title,
+			Severity:        i.Severity,
+			Category:        i.Category,
+			RadiusM:         i.RadiusM,
+			Active:          &active,
+			StartsAt:        i.StartsAt,
+			ExpiresAt:       i.ExpiresAt,
+			CreatedAt:       &createdAt,
+			ApproachBufferM: i.ApproachBufferM,
+			LineBufferM:     i.LineBufferM,
+		},
+	}, nil
+}
+
+// Point превращается в круговую зону с radius_m из properties,
+// Polygon/MultiPolygon — в полигональную, LineString — в линейную с
+// line_buffer_m из properties. ID берётся из properties.id или id фичи:
+// с ID инцидент обновляется (он должен существовать), без ID — создаётся.
+func featureToIncident(f feature) (*domain.Incident, error) {
+	if f.Type != "Feature" {
+		return nil, errors.New("expected Feature")
+	}
+
+	req := createIncidentRequest{
+		Title:           f.Properties.Title,
+		Severity:        f.Properties.Severity,
+		Category:        f.Properties.Category,
+		RadiusM:         f.Properties.RadiusM,
+		StartsAt:        f.Properties.StartsAt,
+		ExpiresAt:       f.Properties.ExpiresAt,
+		ApproachBufferM: f.Properties.ApproachBufferM,
+		LineBufferM:     f.Properties.LineBufferM,
+	}
+
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(f.Geometry, &header); err != nil {
+		return nil, fmt.Errorf("invalid geometry: %w", err)
+	}
+
+	if header.Type == "Point" {
+		var p pointGeometry
+		if err := json.Unmarshal(f.Geometry, &p); err != nil {
+			return nil, fmt.Errorf("invalid geometry: %w", err)
+		}
+		req.Lat, req.Lon = p.Coordinates.Lat(), p.Coordinates.Lon()
+	} else {
+		req.Geometry = &domain.Geometry{}
+		if err := json.Unmarshal(f.Geometry, req.Geometry); err != nil {
+			return nil, fmt.Errorf("invalid geometry: %w", err)
+		}
+	}
+
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	id := f.Properties.ID
+	if id == 0 {
+		id = f.ID
+	} else if f.ID != 0 && f.ID != id {
+		return nil, fmt.Errorf("feature id %d does not match properties.id %d", f.ID, id)
+	}
+
+	active := true
+	if f.Properties.Active != nil {
+		active = *f.Properties.Active
+	}
+
+	return &domain.Incident{
+		ID:              id,
+		Title:           req.Title,
+		Severity:        req.Severity,
+		Category:        req.Category,
+		Lat:             req.Lat,
+		Lon:             req.Lon,
+		RadiusM:         req.RadiusM,
+		Geometry:        req.Geometry,
+		ApproachBufferM: req.ApproachBufferM,
+		LineBufferM:     req.LineBufferM,
+		Active:          active,
+		StartsAt:        req.StartsAt,
+		ExpiresAt:       req.ExpiresAt,
+	}, nil
+}
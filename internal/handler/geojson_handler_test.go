@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+func TestImportGeoJSONMixedFeatures(t *testing.T) {
+	repo := &memoryIncidents{}
+	existing := &domain.Incident{Title: "Old", Lat: 43.24, Lon: 76.89, RadiusM: 100, Active: true}
+	if err := repo.Create(existing); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestIncidentHandler(repo)
+
+	body := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [76.90, 43.25]},
+		 "properties": {"title": "Fire", "radius_m": 300}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[76.88, 43.23], [76.90, 43.23], [76.90, 43.25], [76.88, 43.23]]]},
+		 "properties": {"title": "Flood"}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": "broken"},
+		 "properties": {"title": "Broken"}},
+		{"type": "Feature", "id": 1, "geometry": {"type": "Point", "coordinates": [76.89, 43.24]},
+		 "properties": {"title": "Renamed", "radius_m": 150}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [76.89, 43.24]},
+		 "properties": {"id": 999, "title": "Stale", "radius_m": 150}},
+		{"type": "Feature", "id": 2, "geometry": {"type": "Point", "coordinates": [76.89, 43.24]},
+		 "properties": {"id": 1, "title": "Conflict", "radius_m": 150}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [76.89, 43.24]},
+		 "properties": {"radius_m": 150}}
+	]}`
+
+	rec := httptest.NewRecorder()
+	h.ImportGeoJSON(rec, httptest.NewRequest(http.MethodPost, "/api/v1/incidents/geojson", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var resp importResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Created != 2 || resp.Updated != 1 || resp.Failed != 4 {
+		t.Fatalf("created/updated/failed = %d/%d/%d, want 2/1/4", resp.Created, resp.Updated, resp.Failed)
+	}
+
+	wantStatus := []string{"created", "created", "failed", "updated", "failed", "failed", "failed"}
+	for k, want := range wantStatus {
+		r := resp.Results[k]
+		if r.Index != k || r.Status != want {
+			t.Errorf("result %d = %+v, want status %s", k, r, want)
+		}
+		if (want == "failed") != (r.Error != "") {
+			t.Errorf("result %d: error %q for status %s", k, r.Error, r.Status)
+		}
+	}
+	if !strings.Contains(resp.Results[4].Error, "999 not found") {
+		t.Errorf("stale id error = %q", resp.Results[4].Error)
+	}
+
+	// Неудачные фичи ничего не создали, существующий инцидент обновлён
+	all := repo.all()
+	if len(all) != 3 {
+		t.Fatalf("stored %d incidents, want 3", len(all))
+	}
+	if all[0].ID != 1 || all[0].Title != "Renamed" || all[0].RadiusM != 150 {
+		t.Fatalf("incident 1 = %+v, want renamed with radius 150", all[0])
+	}
+}
+
+func TestImportGeoJSONRejectsNonCollection(t *testing.T) {
+	h := newTestIncidentHandler(&memoryIncidents{})
+
+	rec := httptest.NewRecorder()
+	h.ImportGeoJSON(rec, httptest.NewRequest(http.MethodPost, "/api/v1/incidents/geojson",
+		strings.NewReader(`{"type": "Feature"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
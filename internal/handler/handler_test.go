@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+	"github.com/kassse1/geo-alert-core/internal/service"
+)
+
+// memoryIncidents — IncidentRepository в памяти для тестов обработчиков.
+// Методы, которые тесты не вызывают, остаются у встроенного nil-интерфейса.
+type memoryIncidents struct {
+	repository.IncidentRepository
+
+	mu     sync.Mutex
+	nextID int64
+	rows   map[int64]domain.Incident
+}
+
+func (m *memoryIncidents) Create(i *domain.Incident) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rows == nil {
+		m.rows = make(map[int64]domain.Incident)
+	}
+	m.nextID++
+	i.ID = m.nextID
+	i.CreatedAt = time.Now().UTC()
+	i.UpdatedAt = i.CreatedAt
+	m.rows[i.ID] = *i
+	return nil
+}
+
+func (m *memoryIncidents) GetByID(id int64) (*domain.Incident, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.rows[id]
+	if !ok {
+		return nil, nil
+	}
+	return &i, nil
+}
+
+func (m *memoryIncidents) Update(i *domain.Incident) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.rows[i.ID]
+	if !ok {
+		return nil
+	}
+	i.CreatedAt = stored.CreatedAt
+	i.UpdatedAt = time.Now().UTC()
+	m.rows[i.ID] = *i
+	return nil
+}
+
+// List поддерживает фильтр active, сортировку, курсор и смещение.
+func (m *memoryIncidents) List(f domain.IncidentFilter, p domain.IncidentPage) ([]domain.Incident, error) {
+	order := func(a, b domain.Incident) int {
+		var c int
+		switch p.Sort {
+		case domain.IncidentSortCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case domain.IncidentSortRadius:
+			c = cmp.Compare(a.RadiusM, b.RadiusM)
+		}
+		c = cmp.Or(c, cmp.Compare(a.ID, b.ID))
+		if p.Desc {
+			return -c
+		}
+		return c
+	}
+
+	var page []domain.Incident
+	for _, i := range m.all() {
+		if f.Active != nil && i.Active != *f.Active {
+			continue
+		}
+		if p.After != nil && order(i, domain.Incident{ID: p.After.ID, RadiusM: p.After.RadiusM, CreatedAt: p.After.CreatedAt}) <= 0 {
+			continue
+		}
+		page = append(page, i)
+	}
+	slices.SortFunc(page, order)
+
+	if p.After == nil {
+		page = page[min(p.Offset, len(page)):]
+	}
+	return page[:min(p.Limit, len(page))], nil
+}
+
+func (m *memoryIncidents) Count(f domain.IncidentFilter) (int, error) {
+	all, err := m.List(f, domain.IncidentPage{Limit: len(m.all())})
+	return len(all), err
+}
+
+func (m *memoryIncidents) all() []domain.Incident {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var all []domain.Incident
+	for _, i := range m.rows {
+		all = append(all, i)
+	}
+	sort.Slice(all, func(a, b int) bool { return all[a].ID < all[b].ID })
+	return all
+}
+
+func newTestIncidentHandler(repo repository.IncidentRepository) *IncidentHandler {
+	index := service.NewIncidentIndex(200, service.Haversine{})
+	return NewIncidentHandler(service.NewIncidentService(repo, nil, nil, index), 5)
+}
@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/middleware"
 	"github.com/kassse1/geo-alert-core/internal/service"
 )
 
@@ -33,10 +38,65 @@ POST /api/v1/incidents
 */
 
 type createIncidentRequest struct {
-	Title   string  `json:"title"`
-	Lat     float64 `json:"lat"`
-	Lon     float64 `json:"lon"`
-	RadiusM int     `json:"radius_m"`
+	Title           string           `json:"title"`
+	Severity        string           `json:"severity"`
+	Category        string           `json:"category"`
+	Lat             float64          `json:"lat"`
+	Lon             float64          `json:"lon"`
+	RadiusM         int              `json:"radius_m"`
+	Geometry        *domain.Geometry `json:"geometry"`
+	StartsAt        *time.Time       `json:"starts_at"`
+	ExpiresAt       *time.Time       `json:"expires_at"`
+	ApproachBufferM *int             `json:"approach_buffer_m"`
+	LineBufferM     int              `json:"line_buffer_m"`
+}
+
+const (
+	// Наибольший буфер приближения вокруг зоны.
+	maxApproachBufferM = 50000
+	// Наибольшая ширина полосы линейной зоны (в каждую сторону).
+	maxLineBufferM = 50000
+)
+
+// Зона задаётся либо кругом (lat, lon, radius_m), либо GeoJSON-геометрией;
+// для LineString обязательна ширина полосы line_buffer_m.
+// Пустые severity и category заменяются значениями по умолчанию.
+func (req *createIncidentRequest) validate() error {
+	if req.Title == "" {
+		return errors.New("title is required")
+	}
+	if req.Severity == "" {
+		req.Severity = domain.DefaultSeverity
+	}
+	if domain.SeverityRank(req.Severity) == 0 {
+		return fmt.Errorf("unknown severity %q", req.Severity)
+	}
+	if req.Category == "" {
+		req.Category = domain.DefaultCategory
+	}
+	if !domain.IsKnownCategory(req.Category) {
+		return fmt.Errorf("unknown category %q", req.Category)
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return errors.New("expires_at must be after starts_at")
+	}
+	if b := req.ApproachBufferM; b != nil && (*b < 0 || *b > maxApproachBufferM) {
+		return fmt.Errorf("approach_buffer_m must be between 0 and %d", maxApproachBufferM)
+	}
+	isLine := req.Geometry != nil && req.Geometry.Type == domain.GeometryLineString
+	if isLine && (req.LineBufferM <= 0 || req.LineBufferM > maxLineBufferM) {
+		return fmt.Errorf("line_buffer_m must be between 1 and %d for LineString", maxLineBufferM)
+	}
+	if !isLine && req.LineBufferM != 0 {
+		return errors.New("line_buffer_m is only allowed for LineString geometry")
+	}
+	if req.Geometry != nil {
+		return req.Geometry.Validate()
+	}
+	if req.RadiusM <= 0 {
+		return errors.New("radius_m must be positive")
+	}
+	return nil
 }
 
 func (h *IncidentHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -47,17 +107,25 @@ func (h *IncidentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Title == "" || req.RadiusM <= 0 {
-		http.Error(w, "invalid incident data", http.StatusBadRequest)
+	if err := req.validate(); err != nil {
+		http.Error(w, "invalid incident data: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	incident := &domain.Incident{
-		Title:   req.Title,
-		Lat:     req.Lat,
-		Lon:     req.Lon,
-		RadiusM: req.RadiusM,
-		Active:  true,
+		Title:           req.Title,
+		Severity:        req.Severity,
+		Category:        req.Category,
+		Lat:             req.Lat,
+		Lon:             req.Lon,
+		RadiusM:         req.RadiusM,
+		Geometry:        req.Geometry,
+		ApproachBufferM: req.ApproachBufferM,
+		LineBufferM:     req.LineBufferM,
+		Active:          true,
+		StartsAt:        req.StartsAt,
+		ExpiresAt:       req.ExpiresAt,
+		UpdatedBy:       middleware.Identity(r.Context()),
 	}
 
 	if err := h.service.Create(incident); err != nil {
@@ -73,27 +141,190 @@ func (h *IncidentHandler) Create(w http.ResponseWriter, r *http.Request) {
 /*
 =====================
 LIST
-GET /api/v1/incidents?page&limit
+GET /api/v1/incidents?active&severity&category&created_from&created_to
+                     &bbox=minLon,minLat,maxLon,maxLat
+                     &sort=id|created_at|radius_m&order=asc|desc
+                     &limit&cursor (или page)
 =====================
 */
 
-func (h *IncidentHandler) List(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+const maxListLimit = 500
+
+// Ответ списка — массив инцидентов, как и раньше; общее число и курсор
+// следующей страницы передаются в заголовках.
+const (
+	headerTotalCount = "X-Total-Count"
+	headerNextCursor = "X-Next-Cursor"
+)
+
+// listCursor — содержимое непрозрачного курсора; сортировка сохраняется в
+// нём, чтобы курсор нельзя было применить к другому порядку.
+type listCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d"`
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	RadiusM   int       `json:"r,omitempty"`
+}
+
+func encodeListCursor(page domain.IncidentPage, c domain.IncidentCursor) string {
+	data, _ := json.Marshal(listCursor{
+		Sort:      page.Sort,
+		Desc:      page.Desc,
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		RadiusM:   c.RadiusM,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(s string, page domain.IncidentPage) (*domain.IncidentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	if c.Sort != page.Sort || c.Desc != page.Desc {
+		return nil, errors.New("cursor does not match sort/order")
+	}
+
+	return &domain.IncidentCursor{ID: c.ID, CreatedAt: c.CreatedAt, RadiusM: c.RadiusM}, nil
+}
+
+func parseIncidentListQuery(r *http.Request) (domain.IncidentFilter, domain.IncidentPage, error) {
+	q := r.URL.Query()
+
+	filter := domain.IncidentFilter{
+		Severity: q.Get("severity"),
+		Category: q.Get("category"),
+	}
+	page := domain.IncidentPage{Sort: domain.IncidentSortID, Limit: 10}
+
+	if filter.Severity != "" && domain.SeverityRank(filter.Severity) == 0 {
+		return filter, page, errors.New("invalid severity")
+	}
+	if filter.Category != "" && !domain.IsKnownCategory(filter.Category) {
+		return filter, page, errors.New("invalid category")
+	}
+
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, page, errors.New("invalid active")
+		}
+		filter.Active = &active
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+	} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, page, fmt.Errorf("invalid %s", p.name)
+			}
+			*p.dst = t
+		}
+	}
+
+	if v := q.Get("bbox"); v != "" {
+		parts := strings.Split(v, ",")
+		if len(parts) != 4 {
+			return filter, page, errors.New("invalid bbox: expected minLon,minLat,maxLon,maxLat")
+		}
+		var c [4]float64
+		for idx, part := range parts {
+			f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return filter, page, errors.New("invalid bbox")
+			}
+			c[idx] = f
+		}
+		filter.BBox = &domain.BoundingBox{MinLon: c[0], MinLat: c[1], MaxLon: c[2], MaxLat: c[3]}
+		if c[0] < -180 || c[2] > 180 || c[1] < -90 || c[3] > 90 {
+			return filter, page, errors.New("invalid bbox: coordinates out of range")
+		}
+		if filter.BBox.MinLat > filter.BBox.MaxLat {
+			return filter, page, errors.New("invalid bbox: minLat greater than maxLat")
+		}
+		if filter.BBox.MinLon > filter.BBox.MaxLon {
+			return filter, page, errors.New("bbox crossing the antimeridian (minLon > maxLon) is not supported: split it into two requests")
+		}
+	}
+
+	if v := q.Get("sort"); v != "" {
+		if !domain.IsKnownIncidentSort(v) {
+			return filter, page, errors.New("invalid sort")
+		}
+		page.Sort = v
+	}
 
-	if page <= 0 {
-		page = 1
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return filter, page, errors.New("invalid order")
 	}
-	if limit <= 0 {
-		limit = 10
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, page, errors.New("invalid limit")
+		}
+		page.Limit = min(limit, maxListLimit)
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		after, err := decodeListCursor(v, page)
+		if err != nil {
+			return filter, page, err
+		}
+		page.After = after
+	} else if v := q.Get("page"); v != "" {
+		// Постраничный режим оставлен для совместимости
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return filter, page, errors.New("invalid page")
+		}
+		page.Offset = (n - 1) * page.Limit
+	}
+
+	return filter, page, nil
+}
+
+func (h *IncidentHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, page, err := parseIncidentListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	incidents, err := h.service.List(page, limit)
+	// Запрашиваем на строку больше страницы: если она есть, страница не
+	// последняя и клиенту нужен курсор
+	fetch := page
+	fetch.Limit++
+
+	incidents, total, err := h.service.List(filter, fetch)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set(headerTotalCount, strconv.Itoa(total))
+	if len(incidents) > page.Limit {
+		incidents = incidents[:page.Limit]
+		w.Header().Set(headerNextCursor, encodeListCursor(page, domain.CursorOf(incidents[len(incidents)-1])))
+	}
+
 	if incidents == nil {
 		incidents = []domain.Incident{}
 	}
@@ -105,7 +336,7 @@ func (h *IncidentHandler) List(w http.ResponseWriter, r *http.Request) {
 /*
 =====================
 GET BY ID
-GET /api/v1/incidents/{id}
+GET /api/v1/incidents/{id}?at=RFC3339
 =====================
 */
 
@@ -117,6 +348,16 @@ func (h *IncidentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		at, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			http.Error(w, "invalid at", http.StatusBadRequest)
+			return
+		}
+		h.getAt(w, id, at)
+		return
+	}
+
 	incident, err := h.service.GetByID(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -132,6 +373,72 @@ func (h *IncidentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(incident)
 }
 
+// getAt отвечает состоянием инцидента на момент at из истории версий.
+func (h *IncidentHandler) getAt(w http.ResponseWriter, id int64, at time.Time) {
+	version, err := h.service.GetAt(id, at)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if version == nil || version.Op == domain.VersionOpDelete {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(version.Incident)
+}
+
+/*
+=====================
+HISTORY
+GET /api/v1/incidents/{id}/history
+=====================
+*/
+
+type incidentVersionResponse struct {
+	Version   int             `json:"version"`
+	Op        string          `json:"op"`
+	Actor     string          `json:"actor"`
+	ChangedAt time.Time       `json:"changed_at"`
+	Diff      json.RawMessage `json:"diff"`
+	Incident  domain.Incident `json:"incident"`
+}
+
+func (h *IncidentHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, ok := incidentPathID(w, r, "/history")
+	if !ok {
+		return
+	}
+
+	versions, err := h.service.History(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(versions) == 0 {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	resp := make([]incidentVersionResponse, 0, len(versions))
+	for _, v := range versions {
+		resp = append(resp, incidentVersionResponse{
+			Version:   v.Version,
+			Op:        v.Op,
+			Actor:     v.Actor,
+			ChangedAt: v.ChangedAt,
+			Diff:      v.Diff,
+			Incident:  v.Incident,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
 /*
 =====================
 UPDATE
@@ -153,13 +460,37 @@ func (h *IncidentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := req.validate(); err != nil {
+		http.Error(w, "invalid incident data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// PUT не меняет активность: для этого есть activate/deactivate
+	existing, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
 	incident := &domain.Incident{
-		ID:      id,
-		Title:   req.Title,
-		Lat:     req.Lat,
-		Lon:     req.Lon,
-		RadiusM: req.RadiusM,
-		Active:  true,
+		ID:              id,
+		Title:           req.Title,
+		Severity:        req.Severity,
+		Category:        req.Category,
+		Lat:             req.Lat,
+		Lon:             req.Lon,
+		RadiusM:         req.RadiusM,
+		Geometry:        req.Geometry,
+		ApproachBufferM: req.ApproachBufferM,
+		LineBufferM:     req.LineBufferM,
+		Active:          existing.Active,
+		StartsAt:        req.StartsAt,
+		ExpiresAt:       req.ExpiresAt,
+		UpdatedBy:       middleware.Identity(r.Context()),
 	}
 
 	if err := h.service.Update(incident); err != nil {
@@ -167,30 +498,227 @@ func (h *IncidentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(incident)
 }
 
 /*
 =====================
-DEACTIVATE
+PATCH
+PATCH /api/v1/incidents/{id}
+=====================
+*/
+
+// patchIncidentRequest — только переданные поля меняются. Для geometry,
+// starts_at, expires_at и approach_buffer_m явный null очищает значение.
+type patchIncidentRequest struct {
+	Title           *string         `json:"title"`
+	Severity        *string         `json:"severity"`
+	Category        *string         `json:"category"`
+	Lat             *float64        `json:"lat"`
+	Lon             *float64        `json:"lon"`
+	RadiusM         *int            `json:"radius_m"`
+	Geometry        json.RawMessage `json:"geometry"`
+	StartsAt        json.RawMessage `json:"starts_at"`
+	ExpiresAt       json.RawMessage `json:"expires_at"`
+	ApproachBufferM json.RawMessage `json:"approach_buffer_m"`
+	LineBufferM     *int            `json:"line_buffer_m"`
+}
+
+// apply накладывает изменения на текущее состояние и возвращает запрос
+// для общей валидации.
+func (p patchIncidentRequest) apply(i domain.Incident) (createIncidentRequest, error) {
+	req := createIncidentRequest{
+		Title:           i.Title,
+		Severity:        i.Severity,
+		Category:        i.Category,
+		Lat:             i.Lat,
+		Lon:             i.Lon,
+		RadiusM:         i.RadiusM,
+		Geometry:        i.Geometry,
+		StartsAt:        i.StartsAt,
+		ExpiresAt:       i.ExpiresAt,
+		ApproachBufferM: i.ApproachBufferM,
+		LineBufferM:     i.LineBufferM,
+	}
+
+	if p.Title != nil {
+		req.Title = *p.Title
+	}
+	if p.Severity != nil {
+		req.Severity = *p.Severity
+	}
+	if p.Category != nil {
+		req.Category = *p.Category
+	}
+	if p.Lat != nil {
+		req.Lat = *p.Lat
+	}
+	if p.Lon != nil {
+		req.Lon = *p.Lon
+	}
+	if p.RadiusM != nil {
+		req.RadiusM = *p.RadiusM
+	}
+	// Круг задан явно — полигон больше не действует
+	if p.Geometry == nil && (p.Lat != nil || p.Lon != nil || p.RadiusM != nil) {
+		req.Geometry = nil
+	}
+	if p.Geometry != nil {
+		if err := json.Unmarshal(p.Geometry, &req.Geometry); err != nil {
+			return req, fmt.Errorf("invalid geometry: %w", err)
+		}
+	}
+	// Зона перестала быть линией — ширина полосы больше не действует
+	if p.LineBufferM != nil {
+		req.LineBufferM = *p.LineBufferM
+	} else if req.Geometry == nil || req.Geometry.Type != domain.GeometryLineString {
+		req.LineBufferM = 0
+	}
+	if p.StartsAt != nil {
+		if err := json.Unmarshal(p.StartsAt, &req.StartsAt); err != nil {
+			return req, fmt.Errorf("invalid starts_at: %w", err)
+		}
+	}
+	if p.ExpiresAt != nil {
+		if err := json.Unmarshal(p.ExpiresAt, &req.ExpiresAt); err != nil {
+			return req, fmt.Errorf("invalid expires_at: %w", err)
+		}
+	}
+	if p.ApproachBufferM != nil {
+		req.ApproachBufferM = nil
+		if err := json.Unmarshal(p.ApproachBufferM, &req.ApproachBufferM); err != nil {
+			return req, fmt.Errorf("invalid approach_buffer_m: %w", err)
+		}
+	}
+
+	return req, req.validate()
+}
+
+func (h *IncidentHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, ok := incidentPathID(w, r, "")
+	if !ok {
+		return
+	}
+
+	var patch patchIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	req, err := patch.apply(*existing)
+	if err != nil {
+		http.Error(w, "invalid incident data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	incident := &domain.Incident{
+		ID:              id,
+		Title:           req.Title,
+		Severity:        req.Severity,
+		Category:        req.Category,
+		Lat:             req.Lat,
+		Lon:             req.Lon,
+		RadiusM:         req.RadiusM,
+		Geometry:        req.Geometry,
+		ApproachBufferM: req.ApproachBufferM,
+		LineBufferM:     req.LineBufferM,
+		Active:          existing.Active,
+		StartsAt:        req.StartsAt,
+		ExpiresAt:       req.ExpiresAt,
+		CreatedAt:       existing.CreatedAt,
+		UpdatedBy:       middleware.Identity(r.Context()),
+	}
+
+	if err := h.service.Update(incident); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(incident)
+}
+
+/*
+=====================
+ACTIVATE / DEACTIVATE
+POST   /api/v1/incidents/{id}/activate
+POST   /api/v1/incidents/{id}/deactivate
 DELETE /api/v1/incidents/{id}
 =====================
 */
 
+func (h *IncidentHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	id, ok := incidentPathID(w, r, "/activate")
+	if !ok {
+		return
+	}
+
+	h.writeActionResult(w, h.service.Activate(id, middleware.Identity(r.Context())))
+}
+
 func (h *IncidentHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/incidents/")
+	id, ok := incidentPathID(w, r, "/deactivate")
+	if !ok {
+		return
+	}
+
+	h.writeActionResult(w, h.service.Deactivate(id, middleware.Identity(r.Context())))
+}
+
+/*
+=====================
+PURGE (admin)
+DELETE /api/v1/admin/incidents/{id}
+=====================
+*/
+
+func (h *IncidentHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/admin/incidents/")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id <= 0 {
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.Deactivate(id); err != nil {
+	h.writeActionResult(w, h.service.Purge(id, middleware.Identity(r.Context())))
+}
+
+func (h *IncidentHandler) writeActionResult(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrIncidentNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrIncidentExpired):
+		http.Error(w, "incident expired: extend expires_at first", http.StatusConflict)
+	case err != nil:
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	default:
+		w.WriteHeader(http.StatusNoContent)
 	}
+}
 
-	w.WriteHeader(http.StatusNoContent)
+// incidentPathID разбирает /api/v1/incidents/{id}{suffix}; при ошибке
+// сам отвечает 400.
+func incidentPathID(w http.ResponseWriter, r *http.Request, suffix string) (int64, bool) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/incidents/")
+	idStr = strings.TrimSuffix(idStr, suffix)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
 }
 
 /*
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+func TestUpdateReturnsStoredIncident(t *testing.T) {
+	repo := &memoryIncidents{}
+	existing := &domain.Incident{Title: "Fire", Lat: 43.24, Lon: 76.89, RadiusM: 100, Active: true}
+	if err := repo.Create(existing); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestIncidentHandler(repo)
+
+	body := `{"title": "Updated fire", "lat": 43.24, "lon": 76.89, "radius_m": 600}`
+	rec := httptest.NewRecorder()
+	h.Update(rec, httptest.NewRequest(http.MethodPut, "/api/v1/incidents/1", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var got domain.Incident
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	stored := repo.all()[0]
+	if got.ID != 1 || got.Title != "Updated fire" || got.RadiusM != 600 || !got.Active {
+		t.Fatalf("response = %+v, want updated active incident 1", got)
+	}
+	if !got.CreatedAt.Equal(stored.CreatedAt) || !got.UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Fatalf("response timestamps %v/%v, stored %v/%v", got.CreatedAt, got.UpdatedAt, stored.CreatedAt, stored.UpdatedAt)
+	}
+}
+
+func listIncidents(t *testing.T, h *IncidentHandler, query string) ([]domain.Incident, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.List(rec, httptest.NewRequest(http.MethodGet, "/api/v1/incidents?"+query, nil))
+	if rec.Code != http.StatusOK {
+		return nil, rec
+	}
+
+	var page []domain.Incident
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return page, rec
+}
+
+func TestListCursorPaging(t *testing.T) {
+	repo := &memoryIncidents{}
+	// Одинаковые радиусы проверяют порядок по id внутри равных значений
+	for _, radius := range []int{300, 100, 300, 200, 500} {
+		if err := repo.Create(&domain.Incident{Title: "Zone", Lat: 43.24, Lon: 76.89, RadiusM: radius, Active: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := newTestIncidentHandler(repo)
+
+	cases := []struct {
+		name  string
+		limit string
+		pages [][]int64
+	}{
+		{"last page partial", "2", [][]int64{{5, 3}, {1, 4}, {2}}},
+		{"last page full", "5", [][]int64{{5, 3, 1, 4, 2}}},
+		{"limit above total", "50", [][]int64{{5, 3, 1, 4, 2}}},
+	}
+
+	for _, c := range cases {
+		query := "sort=radius_m&order=desc&limit=" + c.limit
+		cursor := ""
+
+		for k, want := range c.pages {
+			q := query
+			if cursor != "" {
+				q += "&cursor=" + cursor
+			}
+
+			page, rec := listIncidents(t, h, q)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s page %d: status = %d, body %s", c.name, k, rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get(headerTotalCount); got != "5" {
+				t.Errorf("%s page %d: %s = %q, want 5", c.name, k, headerTotalCount, got)
+			}
+
+			ids := make([]int64, 0, len(page))
+			for _, i := range page {
+				ids = append(ids, i.ID)
+			}
+			if !slices.Equal(ids, want) {
+				t.Fatalf("%s page %d: ids = %v, want %v", c.name, k, ids, want)
+			}
+
+			cursor = rec.Header().Get(headerNextCursor)
+			if last := k == len(c.pages)-1; last != (cursor == "") {
+				t.Fatalf("%s page %d: next cursor %q on last=%v", c.name, k, cursor, last)
+			}
+		}
+	}
+}
+
+func TestListRejectsInvalidQuery(t *testing.T) {
+	repo := &memoryIncidents{}
+	for range 3 {
+		if err := repo.Create(&domain.Incident{Title: "Zone", Lat: 43.24, Lon: 76.89, RadiusM: 100, Active: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := newTestIncidentHandler(repo)
+
+	_, rec := listIncidents(t, h, "limit=1")
+	cursor := rec.Header().Get(headerNextCursor)
+	if cursor == "" {
+		t.Fatal("no next cursor on the first page")
+	}
+
+	for _, query := range []string{
+		"limit=1&order=desc&cursor=" + cursor,
+		"cursor=not-a-cursor",
+		"bbox=179,-10,-179,10",
+		"bbox=76,44,77,43",
+		"bbox=-181,43,77,44",
+	} {
+		if _, rec := listIncidents(t, h, query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+
+	_, rec = listIncidents(t, h, "bbox=179,-10,-179,10")
+	if !strings.Contains(rec.Body.String(), "antimeridian") {
+		t.Errorf("antimeridian bbox error = %q", rec.Body)
+	}
+}
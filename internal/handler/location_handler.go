@@ -2,8 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/kassse1/geo-alert-core/internal/domain"
 	"github.com/kassse1/geo-alert-core/internal/service"
 )
 
@@ -15,10 +20,15 @@ func NewLocationHandler(service *service.LocationService) *LocationHandler {
 	return &LocationHandler{service: service}
 }
 
+// speed_mps (м/с) и heading_deg (градусы от севера) передаются вместе;
+// без них движение оценивается по предыдущей проверке пользователя.
 type locationRequest struct {
-	UserID string  `json:"user_id"`
-	Lat    float64 `json:"lat"`
-	Lon    float64 `json:"lon"`
+	UserID     string     `json:"user_id"`
+	Lat        float64    `json:"lat"`
+	Lon        float64    `json:"lon"`
+	SpeedMps   *float64   `json:"speed_mps"`
+	HeadingDeg *float64   `json:"heading_deg"`
+	Timestamp  *time.Time `json:"timestamp"`
 }
 
 func (h *LocationHandler) Check(w http.ResponseWriter, r *http.Request) {
@@ -29,7 +39,25 @@ func (h *LocationHandler) Check(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	incidents, err := h.service.CheckLocation(req.UserID, req.Lat, req.Lon)
+	motion, err := parseMotion(req.SpeedMps, req.HeadingDeg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Проверка «из будущего» оставалась бы последней позицией пользователя
+	// (статистика, рассылка по новой зоне) до тех пор, пока не наступит
+	if err := validateTimestamp(req.Timestamp, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := service.PositionReport{UserID: req.UserID, Lat: req.Lat, Lon: req.Lon, Motion: motion}
+	if req.Timestamp != nil {
+		report.Timestamp = *req.Timestamp
+	}
+
+	incidents, err := h.service.CheckLocation(report)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -38,3 +66,259 @@ func (h *LocationHandler) Check(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(incidents)
 }
+
+/*
+=====================
+BATCH CHECK
+POST /api/v1/location/check/batch
+=====================
+*/
+
+// Ограничение размера пачки (в том числе из-за лимита параметров запроса).
+const maxBatchSize = 1000
+
+type batchLocationItem struct {
+	UserID     string     `json:"user_id"`
+	Lat        *float64   `json:"lat"`
+	Lon        *float64   `json:"lon"`
+	SpeedMps   *float64   `json:"speed_mps"`
+	HeadingDeg *float64   `json:"heading_deg"`
+	Timestamp  *time.Time `json:"timestamp"`
+}
+
+type batchLocationResult struct {
+	Index     int                    `json:"index"`
+	UserID    string                 `json:"user_id"`
+	Incidents []domain.IncidentMatch `json:"incidents"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+func (item batchLocationItem) validate(now time.Time) error {
+	if item.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	if item.Lat == nil || item.Lon == nil {
+		return errors.New("lat and lon are required")
+	}
+	if *item.Lat < -90 || *item.Lat > 90 || *item.Lon < -180 || *item.Lon > 180 {
+		return errors.New("lat/lon out of range")
+	}
+	if err := validateTimestamp(item.Timestamp, now); err != nil {
+		return err
+	}
+	_, err := parseMotion(item.SpeedMps, item.HeadingDeg)
+	return err
+}
+
+const (
+	// Насколько время позиции может опережать часы сервера (расхождение
+	// часов клиента).
+	maxClockSkew = time.Minute
+	// Позиции старше не принимаются: для переходов и истории они уже
+	// неактуальны.
+	maxReportAge = 24 * time.Hour
+)
+
+// validateTimestamp проверяет время позиции от клиента; nil — «сейчас».
+func validateTimestamp(ts *time.Time, now time.Time) error {
+	if ts == nil {
+		return nil
+	}
+	if ts.After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("timestamp is more than %s ahead of server time", maxClockSkew)
+	}
+	if ts.Before(now.Add(-maxReportAge)) {
+		return fmt.Errorf("timestamp is older than %s", maxReportAge)
+	}
+	return nil
+}
+
+// parseMotion проверяет скорость и курс клиента; nil — не переданы.
+func parseMotion(speedMps, headingDeg *float64) (*service.Motion, error) {
+	if speedMps == nil && headingDeg == nil {
+		return nil, nil
+	}
+	if speedMps == nil || headingDeg == nil {
+		return nil, errors.New("speed_mps and heading_deg must be set together")
+	}
+	if *speedMps < 0 || *headingDeg < 0 || *headingDeg >= 360 {
+		return nil, errors.New("speed_mps must be non-negative, heading_deg in [0, 360)")
+	}
+	return &service.Motion{SpeedMps: *speedMps, HeadingDeg: *headingDeg}, nil
+}
+
+func (h *LocationHandler) CheckBatch(w http.ResponseWriter, r *http.Request) {
+	// Элементы разбираются по отдельности: ошибка в одном (например,
+	// строка вместо числа) не отклоняет всю пачку
+	var items []json.RawMessage
+
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(items) > maxBatchSize {
+		http.Error(w, fmt.Sprintf("batch is too large (max %d)", maxBatchSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	now := time.Now()
+	results := make([]batchLocationResult, len(items))
+	reports := make([]service.PositionReport, 0, len(items))
+	positions := make([]int, 0, len(items))
+
+	for idx, raw := range items {
+		var item batchLocationItem
+		err := json.Unmarshal(raw, &item)
+
+		results[idx] = batchLocationResult{Index: idx, UserID: item.UserID}
+
+		if err != nil {
+			results[idx].Error = "invalid item"
+			continue
+		}
+		if err := item.validate(now); err != nil {
+			results[idx].Error = err.Error()
+			continue
+		}
+
+		motion, _ := parseMotion(item.SpeedMps, item.HeadingDeg)
+
+		report := service.PositionReport{UserID: item.UserID, Lat: *item.Lat, Lon: *item.Lon, Motion: motion}
+		if item.Timestamp != nil {
+			report.Timestamp = *item.Timestamp
+		}
+
+		reports = append(reports, report)
+		positions = append(positions, idx)
+	}
+
+	incidents, err := h.service.CheckBatch(reports)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for k, idx := range positions {
+		results[idx].Incidents = incidents[k]
+		if results[idx].Incidents == nil {
+			results[idx].Incidents = []domain.IncidentMatch{}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(results)
+}
+
+type locationCheckResponse struct {
+	ID          int64                        `json:"id"`
+	UserID      string                       `json:"user_id"`
+	Lat         float64                      `json:"lat"`
+	Lon         float64                      `json:"lon"`
+	IncidentIDs []int64                      `json:"incident_ids"`
+	HasDanger   bool                         `json:"has_danger"`
+	DistanceM   *int                         `json:"distance_m"`
+	Matches     []locationCheckMatchResponse `json:"matches"`
+	CheckedAt   time.Time                    `json:"checked_at"`
+}
+
+type locationCheckMatchResponse struct {
+	IncidentID int64  `json:"incident_id"`
+	Status     string `json:"status"`
+	DistanceM  int    `json:"distance_m"`
+}
+
+/*
+=====================
+HISTORY
+GET /api/v1/location/checks?user_id&incident_id&has_danger&from&to&page&limit
+=====================
+*/
+
+func (h *LocationHandler) History(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := domain.LocationCheckFilter{UserID: q.Get("user_id")}
+
+	if v := q.Get("incident_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			http.Error(w, "invalid incident_id", http.StatusBadRequest)
+			return
+		}
+		filter.IncidentID = id
+	}
+
+	if v := q.Get("has_danger"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "invalid has_danger", http.StatusBadRequest)
+			return
+		}
+		filter.HasDanger = &b
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid "+p.name+", expected RFC3339", http.StatusBadRequest)
+				return
+			}
+			*p.dst = t.UTC()
+		}
+	}
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	filter.Offset = (page - 1) * limit
+	filter.Limit = limit
+
+	checks, err := h.service.History(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]locationCheckResponse, 0, len(checks))
+	for _, c := range checks {
+		item := locationCheckResponse{
+			ID:          c.ID,
+			UserID:      c.UserID,
+			Lat:         c.Lat,
+			Lon:         c.Lon,
+			IncidentIDs: c.IncidentIDs,
+			HasDanger:   c.HasDanger,
+			Matches:     make([]locationCheckMatchResponse, 0, len(c.Matches)),
+			CheckedAt:   c.CheckedAt,
+		}
+		for _, m := range c.Matches {
+			item.Matches = append(item.Matches, locationCheckMatchResponse(m))
+		}
+		if c.HasDanger {
+			distance := c.DistanceM
+			item.DistanceM = &distance
+		}
+		if item.IncidentIDs == nil {
+			item.IncidentIDs = []int64{}
+		}
+		resp = append(resp, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
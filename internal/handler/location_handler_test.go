@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTimestamp(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	cases := []struct {
+		name string
+		ts   *time.Time
+		ok   bool
+	}{
+		{"not set", nil, true},
+		{"now", at(0), true},
+		{"within clock skew", at(maxClockSkew), true},
+		{"future", at(maxClockSkew + time.Second), false},
+		{"far future", at(365 * 24 * time.Hour), false},
+		{"an hour ago", at(-time.Hour), true},
+		{"oldest accepted", at(-maxReportAge), true},
+		{"too old", at(-maxReportAge - time.Second), false},
+	}
+
+	for _, c := range cases {
+		if err := validateTimestamp(c.ts, now); (err == nil) != c.ok {
+			t.Errorf("%s: validateTimestamp = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestCheckRejectsFutureTimestamp(t *testing.T) {
+	// До сервиса запрос не доходит
+	h := NewLocationHandler(nil)
+
+	ts := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	body := `{"user_id": "truck-1", "lat": 43.24, "lon": 76.89, "timestamp": "` + ts + `"}`
+
+	rec := httptest.NewRecorder()
+	h.Check(rec, httptest.NewRequest(http.MethodPost, "/api/v1/location/check", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
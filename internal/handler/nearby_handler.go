@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+/*
+=====================
+NEARBY
+GET /api/v1/location/nearby?lat&lon&radius_m&limit
+=====================
+*/
+
+const (
+	defaultNearbyLimit = 10
+	maxNearbyLimit     = 100
+	// Ограничение радиуса поиска, чтобы один запрос не перебирал все зоны.
+	maxNearbyRadiusM = 200000
+)
+
+type nearbyIncidentResponse struct {
+	Incident   domain.Incident `json:"incident"`
+	DistanceM  int             `json:"distance_m"`
+	BearingDeg float64         `json:"bearing_deg"`
+	Direction  string          `json:"direction"`
+	Inside     bool            `json:"inside"`
+}
+
+// Nearby возвращает ближайшие активные зоны, отсортированные по расстоянию
+// до границы. Можно задать радиус поиска, число результатов или оба
+// параметра; без них возвращаются 10 ближайших зон.
+func (h *LocationHandler) Nearby(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+
+	lat, errLat := strconv.ParseFloat(q.Get("lat"), 64)
+	lon, errLon := strconv.ParseFloat(q.Get("lon"), 64)
+	if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		http.Error(w, "invalid lat/lon", http.StatusBadRequest)
+		return
+	}
+
+	var radiusM float64
+	if v := q.Get("radius_m"); v != "" {
+		radius, err := strconv.ParseFloat(v, 64)
+		if err != nil || radius <= 0 || radius > maxNearbyRadiusM {
+			http.Error(w, "invalid radius_m", http.StatusBadRequest)
+			return
+		}
+		radiusM = radius
+	}
+
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxNearbyLimit {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	} else if radiusM == 0 {
+		limit = defaultNearbyLimit
+	}
+
+	nearby := h.service.Nearest(lat, lon, radiusM, limit)
+
+	resp := make([]nearbyIncidentResponse, 0, len(nearby))
+	for _, n := range nearby {
+		resp = append(resp, nearbyIncidentResponse{
+			Incident:   n.Incident,
+			DistanceM:  int(math.Round(n.DistanceM)),
+			BearingDeg: math.Round(n.BearingDeg*10) / 10,
+			Direction:  compassDirection(n.BearingDeg),
+			Inside:     n.Inside,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// compassDirection переводит азимут в одно из 8 направлений (N, NE, ...).
+func compassDirection(bearingDeg float64) string {
+	directions := [...]string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return directions[int(math.Round(bearingDeg/45))%len(directions)]
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/service"
+)
+
+/*
+=====================
+ROUTE CHECK
+POST /api/v1/route/check
+=====================
+*/
+
+const (
+	maxRoutePoints = 10000
+	// Точность encoded polyline по умолчанию (формат Google); 6 — polyline6.
+	defaultPolylinePrecision = 5
+)
+
+// Маршрут передаётся либо GeoJSON LineString в route, либо encoded polyline.
+type routeCheckRequest struct {
+	Route     *routeGeometry `json:"route"`
+	Polyline  string         `json:"polyline"`
+	Precision int            `json:"precision"`
+}
+
+type routeGeometry struct {
+	Type        string            `json:"type"`
+	Coordinates domain.LineString `json:"coordinates"`
+}
+
+type routePointResponse struct {
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	DistanceM int     `json:"distance_m"`
+}
+
+type routeSectionResponse struct {
+	Entry routePointResponse `json:"entry"`
+	Exit  routePointResponse `json:"exit"`
+}
+
+type routeCrossingResponse struct {
+	Incident domain.Incident        `json:"incident"`
+	Sections []routeSectionResponse `json:"sections"`
+}
+
+type routeCheckResponse struct {
+	LengthM   int                     `json:"length_m"`
+	Incidents []routeCrossingResponse `json:"incidents"`
+}
+
+func (req routeCheckRequest) line() (domain.LineString, error) {
+	var line domain.LineString
+
+	switch {
+	case req.Route != nil && req.Polyline != "":
+		return nil, errors.New("either route or polyline is expected, not both")
+	case req.Route != nil:
+		if req.Route.Type != domain.GeometryLineString {
+			return nil, fmt.Errorf("unsupported route type %q, expected LineString", req.Route.Type)
+		}
+		line = req.Route.Coordinates
+	case req.Polyline != "":
+		precision := req.Precision
+		if precision == 0 {
+			precision = defaultPolylinePrecision
+		}
+		if precision < 1 || precision > 7 {
+			return nil, errors.New("precision must be between 1 and 7")
+		}
+		var err error
+		if line, err = decodePolyline(req.Polyline, precision); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("route or polyline is required")
+	}
+
+	if len(line) > maxRoutePoints {
+		return nil, fmt.Errorf("route is too long (max %d points)", maxRoutePoints)
+	}
+	return line, line.Validate()
+}
+
+// CheckRoute возвращает активные зоны, которые пересекает маршрут, с
+// точками входа и выхода и пройденным до них расстоянием.
+func (h *LocationHandler) CheckRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req routeCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	line, err := req.line()
+	if err != nil {
+		http.Error(w, "invalid route: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	crossings, lengthM, err := h.service.RouteCrossings(line)
+	switch {
+	case errors.Is(err, service.ErrRouteTooComplex):
+		http.Error(w, "route is too complex: split it into shorter parts", http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := routeCheckResponse{
+		LengthM:   int(math.Round(lengthM)),
+		Incidents: make([]routeCrossingResponse, 0, len(crossings)),
+	}
+	for _, c := range crossings {
+		item := routeCrossingResponse{
+			Incident: c.Incident,
+			Sections: make([]routeSectionResponse, 0, len(c.Sections)),
+		}
+		for _, s := range c.Sections {
+			item.Sections = append(item.Sections, routeSectionResponse{
+				Entry: routePoint(s.Entry),
+				Exit:  routePoint(s.Exit),
+			})
+		}
+		resp.Incidents = append(resp.Incidents, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func routePoint(p domain.RoutePoint) routePointResponse {
+	return routePointResponse{Lat: p.Lat, Lon: p.Lon, DistanceM: int(math.Round(p.DistanceM))}
+}
+
+// decodePolyline разбирает encoded polyline (формат Google Maps): пары
+// lat/lon — разности с предыдущей точкой, умноженные на 10^precision.
+func decodePolyline(s string, precision int) (domain.LineString, error) {
+	factor := math.Pow(10, float64(precision))
+
+	var (
+		line     domain.LineString
+		lat, lon int64
+	)
+
+	for idx := 0; idx < len(s); {
+		var delta [2]int64
+		for c := range delta {
+			var (
+				result int64
+				shift  uint
+			)
+			for {
+				if idx >= len(s) {
+					return nil, errors.New("truncated polyline")
+				}
+				b := int64(s[idx]) - 63
+				idx++
+				if b < 0 || b > 63 {
+					return nil, fmt.Errorf("invalid polyline character at %d", idx-1)
+				}
+				if shift > 60 {
+					return nil, errors.New("polyline value overflow")
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				delta[c] = ^(result >> 1)
+			} else {
+				delta[c] = result >> 1
+			}
+		}
+
+		lat += delta[0]
+		lon += delta[1]
+		line = append(line, domain.Point{float64(lon) / factor, float64(lat) / factor})
+	}
+
+	return line, nil
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+/*
+=====================
+ALERT STREAM (Server-Sent Events)
+GET /api/v1/location/stream?user_id=...
+=====================
+*/
+
+const (
+	streamHeartbeat = 15 * time.Second
+	// Задержка переподключения, которую сообщаем клиенту (мс).
+	streamRetryMs = 3000
+)
+
+type streamEventData struct {
+	UserID    string                 `json:"user_id"`
+	Incidents []domain.IncidentMatch `json:"incidents"`
+	SentAt    time.Time              `json:"sent_at"`
+}
+
+// Stream держит соединение открытым и отправляет событие "incidents" каждый
+// раз, когда меняется набор зон вокруг последней позиции пользователя.
+// Поток только читает состояние: позиции клиент сообщает через
+// /api/v1/location/check, первое событие приходит после первой проверки
+// (или сразу, если клиент переподключается). При переподключении браузер
+// передаёт Last-Event-ID, и пропущенные события отправляются повторно.
+func (h *LocationHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, replay, unsubscribe := h.service.Subscribe(userID, lastEventID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMs)
+	for _, e := range replay {
+		if err := writeStreamEvent(w, e.ID, e.UserID, e.Incidents, e.At); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				// Клиент отстал или сервер останавливается — пусть переподключится
+				return
+			}
+			if err := writeStreamEvent(w, e.ID, e.UserID, e.Incidents, e.At); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, id uint64, userID string, incidents []domain.IncidentMatch, at time.Time) error {
+	data, err := json.Marshal(streamEventData{UserID: userID, Incidents: incidents, SentAt: at})
+	if err != nil {
+		return err
+	}
+
+	// Событие без ID — снимок текущего состояния, его не нужно повторять
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: incidents\ndata: %s\n\n", data)
+	return err
+}
+
+// parseLastEventID читает заголовок Last-Event-ID (или параметр
+// last_event_id для клиентов, которые не умеют задавать заголовки).
+func parseLastEventID(r *http.Request) (uint64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
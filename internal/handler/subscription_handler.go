@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/service"
+)
+
+type SubscriptionHandler struct {
+	service *service.SubscriptionService
+}
+
+func NewSubscriptionHandler(service *service.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{service: service}
+}
+
+// Secret — nil при обновлении сохраняет прежний секрет (наружу он не
+// отдаётся, поэтому клиент не может прислать его обратно); "" — удаляет.
+type subscriptionRequest struct {
+	URL         string              `json:"url"`
+	Secret      *string             `json:"secret"`
+	EventTypes  []string            `json:"event_types"`
+	IncidentIDs []int64             `json:"incident_ids"`
+	BBox        *domain.BoundingBox `json:"bbox"`
+	MinSeverity string              `json:"min_severity"`
+	Active      *bool               `json:"active"`
+}
+
+// Секрет наружу не отдаётся — только признак его наличия.
+type subscriptionResponse struct {
+	ID          int64               `json:"id"`
+	URL         string              `json:"url"`
+	HasSecret   bool                `json:"has_secret"`
+	EventTypes  []string            `json:"event_types"`
+	IncidentIDs []int64             `json:"incident_ids"`
+	BBox        *domain.BoundingBox `json:"bbox"`
+	MinSeverity string              `json:"min_severity,omitempty"`
+	Active      bool                `json:"active"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+func (req subscriptionRequest) validate() error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	for _, t := range req.EventTypes {
+		if !domain.IsKnownEventType(t) {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	if b := req.BBox; b != nil {
+		if b.MinLat > b.MaxLat || b.MinLon > b.MaxLon ||
+			b.MinLat < -90 || b.MaxLat > 90 || b.MinLon < -180 || b.MaxLon > 180 {
+			return errors.New("invalid bbox")
+		}
+	}
+	if req.MinSeverity != "" && domain.SeverityRank(req.MinSeverity) == 0 {
+		return fmt.Errorf("unknown severity %q", req.MinSeverity)
+	}
+	return nil
+}
+
+func (req subscriptionRequest) toDomain(id int64) *domain.Subscription {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	var secret string
+	if req.Secret != nil {
+		secret = *req.Secret
+	}
+
+	return &domain.Subscription{
+		ID:          id,
+		URL:         req.URL,
+		Secret:      secret,
+		EventTypes:  req.EventTypes,
+		IncidentIDs: req.IncidentIDs,
+		BBox:        req.BBox,
+		MinSeverity: req.MinSeverity,
+		Active:      active,
+	}
+}
+
+func toSubscriptionResponse(s domain.Subscription) subscriptionResponse {
+	resp := subscriptionResponse{
+		ID:          s.ID,
+		URL:         s.URL,
+		HasSecret:   s.Secret != "",
+		EventTypes:  s.EventTypes,
+		IncidentIDs: s.IncidentIDs,
+		BBox:        s.BBox,
+		MinSeverity: s.MinSeverity,
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt,
+	}
+	if resp.EventTypes == nil {
+		resp.EventTypes = []string{}
+	}
+	if resp.IncidentIDs == nil {
+		resp.IncidentIDs = []int64{}
+	}
+	return resp
+}
+
+/*
+=====================
+CREATE
+POST /api/v1/subscriptions
+=====================
+*/
+
+func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req subscriptionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		http.Error(w, "invalid subscription data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subscription := req.toDomain(0)
+
+	if err := h.service.Create(subscription); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(toSubscriptionResponse(*subscription))
+}
+
+/*
+=====================
+LIST
+GET /api/v1/subscriptions
+=====================
+*/
+
+func (h *SubscriptionHandler) List(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.service.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]subscriptionResponse, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		resp = append(resp, toSubscriptionResponse(s))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+/*
+=====================
+GET BY ID
+GET /api/v1/subscriptions/{id}
+=====================
+*/
+
+func (h *SubscriptionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if subscription == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toSubscriptionResponse(*subscription))
+}
+
+/*
+=====================
+UPDATE
+PUT /api/v1/subscriptions/{id}
+=====================
+*/
+
+func (h *SubscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req subscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		http.Error(w, "invalid subscription data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subscription := req.toDomain(id)
+
+	if req.Secret == nil {
+		current, err := h.service.GetByID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if current == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		subscription.Secret = current.Secret
+	}
+
+	if err := h.service.Update(subscription); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+=====================
+DELETE
+DELETE /api/v1/subscriptions/{id}
+=====================
+*/
+
+func (h *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func subscriptionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/subscriptions/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
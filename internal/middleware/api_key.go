@@ -1,17 +1,44 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 )
 
-func APIKeyMiddleware(expectedKey string, next http.Handler) http.Handler {
+type identityKey struct{}
+
+// APIKeyMiddleware пропускает запросы с известным X-API-Key; keys —
+// соответствие ключа идентификатору его владельца. Идентификатор
+// доступен обработчикам через Identity.
+func APIKeyMiddleware(keys map[string]string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		key := r.Header.Get("X-API-Key")
-		if key == "" || key != expectedKey {
+		identity, ok := keys[key]
+		if key == "" || !ok {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
 
+		ctx := context.WithValue(r.Context(), identityKey{}, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Identity возвращает идентификатор API-ключа запроса ("" без ключа).
+func Identity(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(string)
+	return identity
+}
+
+// AdminMiddleware пропускает только владельцев ключей из admins; ставится
+// после APIKeyMiddleware.
+func AdminMiddleware(admins map[string]bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !admins[Identity(r.Context())] {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+type cooldownKey struct {
+	kind       string
+	userID     string
+	incidentID int64
+}
+
+// AlertCooldownMemoryRepository — кулдаун в памяти процесса,
+// подходит только для запуска в одном экземпляре.
+type AlertCooldownMemoryRepository struct {
+	mu         sync.Mutex
+	notifiedAt map[cooldownKey]time.Time
+	lastPrune  time.Time
+}
+
+func NewAlertCooldownMemoryRepository() *AlertCooldownMemoryRepository {
+	return &AlertCooldownMemoryRepository{
+		notifiedAt: make(map[cooldownKey]time.Time),
+	}
+}
+
+func (r *AlertCooldownMemoryRepository) Acquire(
+	kind string,
+	userID string,
+	incidentIDs []int64,
+	window time.Duration,
+) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	// Раз в окно удаляются истёкшие записи, даже без вызова Prune
+	if now.Sub(r.lastPrune) >= window {
+		r.prune(now, window)
+	}
+
+	var allowed []int64
+	for _, id := range incidentIDs {
+		key := cooldownKey{kind: kind, userID: userID, incidentID: id}
+		if t, ok := r.notifiedAt[key]; ok && now.Sub(t) < window {
+			continue
+		}
+		r.notifiedAt[key] = now
+		allowed = append(allowed, id)
+	}
+
+	return allowed, nil
+}
+
+func (r *AlertCooldownMemoryRepository) Release(kind, userID string, incidentIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range incidentIDs {
+		delete(r.notifiedAt, cooldownKey{kind: kind, userID: userID, incidentID: id})
+	}
+	return nil
+}
+
+func (r *AlertCooldownMemoryRepository) Prune(window time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.prune(time.Now(), window), nil
+}
+
+// prune удаляет истёкшие записи, чтобы карта не росла бесконечно.
+func (r *AlertCooldownMemoryRepository) prune(now time.Time, window time.Duration) int64 {
+	var n int64
+	for key, t := range r.notifiedAt {
+		if now.Sub(t) >= window {
+			delete(r.notifiedAt, key)
+			n++
+		}
+	}
+	r.lastPrune = now
+	return n
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AlertCooldownPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewAlertCooldownPostgresRepository(db *sql.DB) *AlertCooldownPostgresRepository {
+	return &AlertCooldownPostgresRepository{db: db}
+}
+
+// Acquire атомарен между репликами: строка обновляется, только если
+// прошлое уведомление старше окна.
+func (r *AlertCooldownPostgresRepository) Acquire(
+	kind string,
+	userID string,
+	incidentIDs []int64,
+	window time.Duration,
+) ([]int64, error) {
+	if len(incidentIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		INSERT INTO alert_cooldowns (user_id, incident_id, kind, notified_at)
+		SELECT $1, unnest($2::bigint[]), $4, now()
+		ON CONFLICT (user_id, incident_id, kind) DO UPDATE
+		SET notified_at = EXCLUDED.notified_at
+		WHERE alert_cooldowns.notified_at <= now() - make_interval(secs => $3)
+		RETURNING incident_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID, incidentIDs, window.Seconds(), kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allowed []int64
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		allowed = append(allowed, id)
+	}
+
+	return allowed, rows.Err()
+}
+
+func (r *AlertCooldownPostgresRepository) Release(kind, userID string, incidentIDs []int64) error {
+	if len(incidentIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		DELETE FROM alert_cooldowns
+		WHERE user_id = $1 AND kind = $2 AND incident_id = ANY($3::bigint[])
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userID, kind, incidentIDs)
+	return err
+}
+
+func (r *AlertCooldownPostgresRepository) Prune(window time.Duration) (int64, error) {
+	query := `
+		DELETE FROM alert_cooldowns
+		WHERE notified_at <= now() - make_interval(secs => $1)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, query, window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
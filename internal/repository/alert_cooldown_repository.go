@@ -0,0 +1,18 @@
+package repository
+
+import "time"
+
+type AlertCooldownRepository interface {
+	// Acquire возвращает ID инцидентов, по которым пользователь не
+	// уведомлялся событиями вида kind в течение window, и отмечает их как
+	// уведомлённые. Кулдауны разных видов независимы.
+	Acquire(kind, userID string, incidentIDs []int64, window time.Duration) ([]int64, error)
+
+	// Release снимает кулдаун, взятый Acquire, если уведомление так и не
+	// удалось отправить.
+	Release(kind, userID string, incidentIDs []int64) error
+
+	// Prune удаляет записи старше window — на Acquire они уже не влияют —
+	// и возвращает их число.
+	Prune(window time.Duration) (int64, error)
+}
@@ -0,0 +1,73 @@
+package repository_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+func TestAlertCooldownMemoryRepository(t *testing.T) {
+	testAlertCooldownRepository(t, repository.NewAlertCooldownMemoryRepository())
+}
+
+func TestAlertCooldownPostgresRepository(t *testing.T) {
+	db := testDB(t)
+	if _, err := db.Exec(`TRUNCATE alert_cooldowns`); err != nil {
+		t.Fatalf("truncate alert_cooldowns: %v", err)
+	}
+	testAlertCooldownRepository(t, repository.NewAlertCooldownPostgresRepository(db.DB))
+}
+
+func testAlertCooldownRepository(t *testing.T, repo repository.AlertCooldownRepository) {
+	acquire := func(ids []int64, window time.Duration) []int64 {
+		t.Helper()
+		allowed, err := repo.Acquire("inside", "truck-1", ids, window)
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		slices.Sort(allowed)
+		return allowed
+	}
+
+	const short = 100 * time.Millisecond
+
+	if got := acquire([]int64{1, 2}, short); !slices.Equal(got, []int64{1, 2}) {
+		t.Fatalf("first Acquire = %v, want [1 2]", got)
+	}
+	time.Sleep(2 * short)
+
+	// Свежая запись переживает Prune
+	if got := acquire([]int64{3}, time.Hour); !slices.Equal(got, []int64{3}) {
+		t.Fatalf("Acquire = %v, want [3]", got)
+	}
+
+	n, err := repo.Prune(short)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Prune removed %d rows, want 2", n)
+	}
+
+	// Удалённые записи больше не держат кулдаун даже при длинном окне
+	if got := acquire([]int64{1, 2, 3}, time.Hour); !slices.Equal(got, []int64{1, 2}) {
+		t.Fatalf("Acquire after Prune = %v, want [1 2]", got)
+	}
+
+	// Release снимает только свой вид кулдауна
+	if _, err := repo.Acquire("approaching", "truck-1", []int64{1}, time.Hour); err != nil {
+		t.Fatalf("Acquire approaching: %v", err)
+	}
+	if err := repo.Release("inside", "truck-1", []int64{1, 3}); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := acquire([]int64{1, 2, 3}, time.Hour); !slices.Equal(got, []int64{1, 3}) {
+		t.Fatalf("Acquire after Release = %v, want [1 3]", got)
+	}
+	allowed, err := repo.Acquire("approaching", "truck-1", []int64{1}, time.Hour)
+	if err != nil || len(allowed) != 0 {
+		t.Fatalf("approaching Acquire after Release = %v, %v; want none", allowed, err)
+	}
+}
@@ -0,0 +1,444 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+	"github.com/kassse1/geo-alert-core/internal/service"
+)
+
+// Окрестности точки (43.24, 76.89); все зоны набора лежат рядом с ней.
+const (
+	baseLat = 43.24
+	baseLon = 76.89
+)
+
+// testIncidentRepository — общий набор проверок контракта
+// repository.IncidentRepository для всех реализаций. newRepo вызывается для
+// каждого подтеста и возвращает репозиторий над пустой таблицей incidents.
+func testIncidentRepository(t *testing.T, newRepo func(t *testing.T) repository.IncidentRepository) {
+	t.Run("CreateGetUpdate", func(t *testing.T) { testCreateGetUpdate(t, newRepo(t)) })
+	t.Run("ActivateDeactivateDelete", func(t *testing.T) { testActivateDeactivateDelete(t, newRepo(t)) })
+	t.Run("ListCount", func(t *testing.T) { testListCount(t, newRepo(t)) })
+	t.Run("Schedule", func(t *testing.T) { testSchedule(t, newRepo(t)) })
+}
+
+// testSpatialIncidentRepository проверяет поиск зон по точке.
+func testSpatialIncidentRepository(t *testing.T, newRepo func(t *testing.T) repository.SpatialIncidentRepository) {
+	t.Run("ActiveContaining", func(t *testing.T) { testActiveContaining(t, newRepo(t)) })
+	t.Run("ActiveNear", func(t *testing.T) { testActiveNear(t, newRepo(t)) })
+}
+
+func testCreateGetUpdate(t *testing.T, repo repository.IncidentRepository) {
+	buffer := 150
+	starts := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	i := create(t, repo, domain.Incident{
+		Title:           "flood",
+		Severity:        domain.SeverityCritical,
+		Category:        "weather",
+		Lat:             baseLat,
+		Lon:             baseLon,
+		RadiusM:         500,
+		ApproachBufferM: &buffer,
+		Active:          true,
+		StartsAt:        &starts,
+		UpdatedBy:       "alice",
+	})
+	if i.CreatedAt.IsZero() || i.UpdatedAt.IsZero() {
+		t.Fatalf("Create did not fill timestamps: %+v", i)
+	}
+
+	got := get(t, repo, i.ID)
+	if got.Title != "flood" || got.Severity != domain.SeverityCritical || got.Category != "weather" ||
+		got.Lat != baseLat || got.Lon != baseLon || got.RadiusM != 500 || !got.Active ||
+		got.UpdatedBy != "alice" || got.Geometry != nil || got.ExpiresAt != nil {
+		t.Fatalf("GetByID = %+v, want fields of %+v", got, i)
+	}
+	if got.ApproachBufferM == nil || *got.ApproachBufferM != 150 {
+		t.Fatalf("ApproachBufferM = %v, want 150", got.ApproachBufferM)
+	}
+	if got.StartsAt == nil || !got.StartsAt.Equal(starts) {
+		t.Fatalf("StartsAt = %v, want %v", got.StartsAt, starts)
+	}
+
+	got.Title = "flood (updated)"
+	got.RadiusM = 800
+	got.ApproachBufferM = nil
+	got.StartsAt = nil
+	got.Geometry = &domain.Geometry{
+		Type: domain.GeometryLineString,
+		Line: domain.LineString{{baseLon, baseLat}, {baseLon + 0.01, baseLat}},
+	}
+	got.LineBufferM = 50
+	got.UpdatedBy = "bob"
+	if err := repo.Update(got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	updated := get(t, repo, i.ID)
+	if updated.Title != "flood (updated)" || updated.RadiusM != 800 || updated.ApproachBufferM != nil ||
+		updated.StartsAt != nil || updated.LineBufferM != 50 || updated.UpdatedBy != "bob" {
+		t.Fatalf("after Update GetByID = %+v", updated)
+	}
+	if updated.Geometry == nil || updated.Geometry.Type != domain.GeometryLineString || len(updated.Geometry.Line) != 2 {
+		t.Fatalf("Geometry = %+v, want LineString of 2 points", updated.Geometry)
+	}
+
+	missing, err := repo.GetByID(i.ID + 1000000)
+	if err != nil || missing != nil {
+		t.Fatalf("GetByID(missing) = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func testActivateDeactivateDelete(t *testing.T, repo repository.IncidentRepository) {
+	i := create(t, repo, circle("road works", baseLat, baseLon, 300))
+
+	if !hasID(active(t, repo), i.ID) {
+		t.Fatalf("GetActive does not contain new incident %d", i.ID)
+	}
+
+	if err := repo.Deactivate(i.ID, "alice"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if got := get(t, repo, i.ID); got.Active || got.UpdatedBy != "alice" {
+		t.Fatalf("after Deactivate GetByID = %+v", got)
+	}
+	if hasID(active(t, repo), i.ID) {
+		t.Fatalf("GetActive contains deactivated incident %d", i.ID)
+	}
+
+	if err := repo.Activate(i.ID, "bob"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if got := get(t, repo, i.ID); !got.Active || got.UpdatedBy != "bob" {
+		t.Fatalf("after Activate GetByID = %+v", got)
+	}
+
+	if err := repo.Delete(i.ID, "admin"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	got, err := repo.GetByID(i.ID)
+	if err != nil || got != nil {
+		t.Fatalf("after Delete GetByID = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func testListCount(t *testing.T, repo repository.IncidentRepository) {
+	var ids []int64
+	for k, severity := range []string{domain.SeverityInfo, domain.SeverityWarning, domain.SeverityCritical} {
+		i := circle("zone", baseLat+float64(k), baseLon, 100*(k+1))
+		i.Severity = severity
+		ids = append(ids, create(t, repo, i).ID)
+	}
+	if err := repo.Deactivate(ids[0], "alice"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+
+	yes, no := true, false
+	cases := []struct {
+		name   string
+		filter domain.IncidentFilter
+		want   []int64
+	}{
+		{"all", domain.IncidentFilter{}, ids},
+		{"active", domain.IncidentFilter{Active: &yes}, ids[1:]},
+		{"inactive", domain.IncidentFilter{Active: &no}, ids[:1]},
+		{"severity", domain.IncidentFilter{Severity: domain.SeverityWarning}, ids[1:2]},
+		{"bbox", domain.IncidentFilter{BBox: &domain.BoundingBox{
+			MinLat: baseLat + 0.5, MinLon: baseLon - 1, MaxLat: baseLat + 3, MaxLon: baseLon + 1,
+		}}, ids[1:]},
+		// Центр третьей зоны в ~200 м южнее прямоугольника, радиус 300 м
+		{"bbox touches circle", domain.IncidentFilter{BBox: &domain.BoundingBox{
+			MinLat: baseLat + 2.0018, MinLon: baseLon - 1, MaxLat: baseLat + 3, MaxLon: baseLon + 1,
+		}}, ids[2:]},
+		{"bbox misses circle", domain.IncidentFilter{BBox: &domain.BoundingBox{
+			MinLat: baseLat + 2.0036, MinLon: baseLon - 1, MaxLat: baseLat + 3, MaxLon: baseLon + 1,
+		}}, nil},
+	}
+
+	for _, c := range cases {
+		got, err := repo.List(c.filter, domain.IncidentPage{Sort: domain.IncidentSortID, Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: List: %v", c.name, err)
+		}
+		if !sameIDs(got, c.want) {
+			t.Fatalf("%s: List = %v, want %v", c.name, idsOf(got), c.want)
+		}
+
+		n, err := repo.Count(c.filter)
+		if err != nil {
+			t.Fatalf("%s: Count: %v", c.name, err)
+		}
+		if n != len(c.want) {
+			t.Fatalf("%s: Count = %d, want %d", c.name, n, len(c.want))
+		}
+	}
+
+	// Keyset-страницы по radius_m в обратном порядке
+	page := domain.IncidentPage{Sort: domain.IncidentSortRadius, Desc: true, Limit: 2}
+	first, err := repo.List(domain.IncidentFilter{}, page)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !sameIDs(first, []int64{ids[2], ids[1]}) {
+		t.Fatalf("first page = %v, want %v", idsOf(first), []int64{ids[2], ids[1]})
+	}
+
+	cursor := domain.CursorOf(first[len(first)-1])
+	page.After = &cursor
+	second, err := repo.List(domain.IncidentFilter{}, page)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !sameIDs(second, ids[:1]) {
+		t.Fatalf("second page = %v, want %v", idsOf(second), ids[:1])
+	}
+}
+
+func testSchedule(t *testing.T, repo repository.IncidentRepository) {
+	now := time.Now().UTC().Truncate(time.Second)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+
+	expired := circle("expired", baseLat, baseLon, 100)
+	expired.ExpiresAt = &past
+	expiredID := create(t, repo, expired).ID
+
+	pending := circle("pending", baseLat, baseLon, 100)
+	pending.StartsAt = &future
+	pendingID := create(t, repo, pending).ID
+
+	current := active(t, repo)
+	if hasID(current, expiredID) || hasID(current, pendingID) {
+		t.Fatalf("GetActive = %v, must not contain %d (expired) or %d (pending)", idsOf(current), expiredID, pendingID)
+	}
+
+	due, err := repo.ExpireDue(now)
+	if err != nil {
+		t.Fatalf("ExpireDue: %v", err)
+	}
+	if !sameIDs(due, []int64{expiredID}) {
+		t.Fatalf("ExpireDue = %v, want [%d]", idsOf(due), expiredID)
+	}
+	if got := get(t, repo, expiredID); got.Active || got.UpdatedBy != domain.ActorSystem {
+		t.Fatalf("after ExpireDue GetByID = %+v", got)
+	}
+	if again, err := repo.ExpireDue(now); err != nil || len(again) != 0 {
+		t.Fatalf("repeated ExpireDue = %v, %v; want none", idsOf(again), err)
+	}
+
+	started, err := repo.StartedBetween(now, future)
+	if err != nil {
+		t.Fatalf("StartedBetween: %v", err)
+	}
+	if !sameIDs(started, []int64{pendingID}) {
+		t.Fatalf("StartedBetween = %v, want [%d]", idsOf(started), pendingID)
+	}
+	if started, err := repo.StartedBetween(future, future.Add(time.Hour)); err != nil || len(started) != 0 {
+		t.Fatalf("StartedBetween after start = %v, %v; want none", idsOf(started), err)
+	}
+
+	// Начало действия захватывает только первый вызов
+	for k, want := range []bool{true, false} {
+		claimed, err := repo.ClaimStart(pendingID, future)
+		if err != nil {
+			t.Fatalf("ClaimStart: %v", err)
+		}
+		if claimed != want {
+			t.Fatalf("ClaimStart call %d = %v, want %v", k+1, claimed, want)
+		}
+	}
+	if claimed, err := repo.ClaimStart(pendingID, future.Add(time.Hour)); err != nil || !claimed {
+		t.Fatalf("ClaimStart with new starts_at = %v, %v; want true", claimed, err)
+	}
+}
+
+func testActiveContaining(t *testing.T, repo repository.SpatialIncidentRepository) {
+	// Круг радиусом 1 км, квадрат ~2x2 км с дырой ~0.5x0.5 км и полоса
+	// шириной 200 м вдоль линии на восток — в разных местах вокруг base.
+	circleID := create(t, repo, circle("circle", baseLat, baseLon, 1000)).ID
+
+	square := domain.Incident{
+		Title:    "polygon",
+		Severity: domain.SeverityWarning,
+		Active:   true,
+		Geometry: &domain.Geometry{Type: domain.GeometryPolygon, Polygons: []domain.Polygon{{
+			{{baseLon + 0.1, baseLat}, {baseLon + 0.125, baseLat}, {baseLon + 0.125, baseLat + 0.02},
+				{baseLon + 0.1, baseLat + 0.02}, {baseLon + 0.1, baseLat}},
+			{{baseLon + 0.11, baseLat + 0.008}, {baseLon + 0.116, baseLat + 0.008},
+				{baseLon + 0.116, baseLat + 0.012}, {baseLon + 0.11, baseLat + 0.012}, {baseLon + 0.11, baseLat + 0.008}},
+		}}},
+	}
+	square.Lat, square.Lon, square.RadiusM = baseLat+0.01, baseLon+0.1125, 2000
+	polygonID := create(t, repo, square).ID
+
+	line := domain.Incident{
+		Title:       "line",
+		Severity:    domain.SeverityInfo,
+		Active:      true,
+		LineBufferM: 200,
+		Geometry: &domain.Geometry{Type: domain.GeometryLineString, Line: domain.LineString{
+			{baseLon, baseLat - 0.1}, {baseLon + 0.05, baseLat - 0.1},
+		}},
+	}
+	line.Lat, line.Lon, line.RadiusM = baseLat-0.1, baseLon+0.025, 2300
+	lineID := create(t, repo, line).ID
+
+	inactive := circle("inactive", baseLat, baseLon, 1000)
+	inactive.Active = false
+	create(t, repo, inactive)
+
+	points := []struct {
+		name     string
+		lat, lon float64
+		want     []int64
+	}{
+		{"circle center", baseLat, baseLon, []int64{circleID}},
+		{"circle inside near border", baseLat + 0.0085, baseLon, []int64{circleID}},
+		{"circle outside near border", baseLat + 0.0095, baseLon, nil},
+		{"polygon inside", baseLat + 0.004, baseLon + 0.105, []int64{polygonID}},
+		{"polygon hole", baseLat + 0.01, baseLon + 0.113, nil},
+		{"polygon outside", baseLat + 0.03, baseLon + 0.105, nil},
+		{"line on axis", baseLat - 0.1, baseLon + 0.02, []int64{lineID}},
+		{"line inside buffer", baseLat - 0.1015, baseLon + 0.02, []int64{lineID}},
+		{"line outside buffer", baseLat - 0.1025, baseLon + 0.02, nil},
+		{"line beyond end", baseLat - 0.1, baseLon + 0.055, nil},
+	}
+
+	// Поиск на стороне БД должен совпадать с проверкой сервиса по формам
+	// зон (эллипсоид WGS-84, как в PostGIS)
+	all := active(t, repo)
+
+	for _, p := range points {
+		got, err := repo.ActiveContaining(p.lat, p.lon)
+		if err != nil {
+			t.Fatalf("%s: ActiveContaining: %v", p.name, err)
+		}
+		if !sameIDs(got, p.want) {
+			t.Fatalf("%s: ActiveContaining(%v, %v) = %v, want %v", p.name, p.lat, p.lon, idsOf(got), p.want)
+		}
+
+		var expected []int64
+		for _, i := range all {
+			if service.IncidentContains(service.Vincenty{}, i, p.lat, p.lon) {
+				expected = append(expected, i.ID)
+			}
+		}
+		if !sameIDs(got, expected) {
+			t.Fatalf("%s: ActiveContaining = %v, service.IncidentContains = %v", p.name, idsOf(got), expected)
+		}
+	}
+}
+
+func testActiveNear(t *testing.T, repo repository.SpatialIncidentRepository) {
+	// Три круга радиусом 1 км: со своим буфером 300 м, с буфером по
+	// умолчанию (200 м) и без буфера.
+	own := circle("own buffer", baseLat, baseLon, 1000)
+	ownBuffer := 300
+	own.ApproachBufferM = &ownBuffer
+	ownID := create(t, repo, own).ID
+
+	defaultID := create(t, repo, circle("default buffer", baseLat+0.1, baseLon, 1000)).ID
+
+	none := circle("no buffer", baseLat-0.1, baseLon, 1000)
+	noBuffer := 0
+	none.ApproachBufferM = &noBuffer
+	noneID := create(t, repo, none).ID
+
+	points := []struct {
+		name     string
+		lat, lon float64
+		want     []int64
+	}{
+		{"own buffer", baseLat + 0.0115, baseLon, []int64{ownID}},
+		{"beyond own buffer", baseLat + 0.0125, baseLon, nil},
+		{"default buffer", baseLat + 0.1105, baseLon, []int64{defaultID}},
+		{"beyond default buffer", baseLat + 0.1115, baseLon, nil},
+		{"inside without buffer", baseLat - 0.1, baseLon, []int64{noneID}},
+		{"outside without buffer", baseLat - 0.0905, baseLon, nil},
+	}
+
+	for _, p := range points {
+		got, err := repo.ActiveNear(p.lat, p.lon, 200)
+		if err != nil {
+			t.Fatalf("%s: ActiveNear: %v", p.name, err)
+		}
+		if !sameIDs(got, p.want) {
+			t.Fatalf("%s: ActiveNear(%v, %v) = %v, want %v", p.name, p.lat, p.lon, idsOf(got), p.want)
+		}
+	}
+}
+
+func circle(title string, lat, lon float64, radiusM int) domain.Incident {
+	return domain.Incident{
+		Title:    title,
+		Severity: domain.SeverityWarning,
+		Lat:      lat,
+		Lon:      lon,
+		RadiusM:  radiusM,
+		Active:   true,
+	}
+}
+
+func create(t *testing.T, repo repository.IncidentRepository, i domain.Incident) *domain.Incident {
+	t.Helper()
+	if err := repo.Create(&i); err != nil {
+		t.Fatalf("Create(%q): %v", i.Title, err)
+	}
+	if i.ID == 0 {
+		t.Fatalf("Create(%q) did not assign ID", i.Title)
+	}
+	return &i
+}
+
+func get(t *testing.T, repo repository.IncidentRepository, id int64) *domain.Incident {
+	t.Helper()
+	i, err := repo.GetByID(id)
+	if err != nil {
+		t.Fatalf("GetByID(%d): %v", id, err)
+	}
+	if i == nil {
+		t.Fatalf("GetByID(%d): not found", id)
+	}
+	return i
+}
+
+func active(t *testing.T, repo repository.IncidentRepository) []domain.Incident {
+	t.Helper()
+	incidents, err := repo.GetActive()
+	if err != nil {
+		t.Fatalf("GetActive: %v", err)
+	}
+	return incidents
+}
+
+func idsOf(incidents []domain.Incident) []int64 {
+	ids := make([]int64, 0, len(incidents))
+	for _, i := range incidents {
+		ids = append(ids, i.ID)
+	}
+	return ids
+}
+
+func hasID(incidents []domain.Incident, id int64) bool {
+	for _, i := range incidents {
+		if i.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sameIDs сравнивает ID с учётом порядка.
+func sameIDs(incidents []domain.Incident, want []int64) bool {
+	if len(incidents) != len(want) {
+		return false
+	}
+	for k, i := range incidents {
+		if i.ID != want[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// IncidentPostGISRepository хранит инциденты в тех же таблицах, что и
+// IncidentPostgresRepository, и ищет зоны по точке через PostGIS: таблица
+// incident_areas с GiST-индексом поддерживается триггером
+// (migrations/postgis).
+type IncidentPostGISRepository struct {
+	*IncidentPostgresRepository
+}
+
+func NewIncidentPostGISRepository(db *sql.DB) *IncidentPostGISRepository {
+	return &IncidentPostGISRepository{
+		IncidentPostgresRepository: NewIncidentPostgresRepository(db),
+	}
+}
+
+// ActiveContaining сначала отбирает зоны по индексу (bounds), затем
+// проверяет расстояние до формы зоны на эллипсоиде WGS-84.
+func (r *IncidentPostGISRepository) ActiveContaining(lat, lon float64) ([]domain.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		JOIN incident_areas ON incident_areas.incident_id = incidents.id
+		WHERE active = TRUE
+		  AND (starts_at IS NULL OR starts_at <= $3)
+		  AND (expires_at IS NULL OR expires_at > $3)
+		  AND bounds && ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography
+		  AND ST_DWithin(shape, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography, reach_m)
+		ORDER BY id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, lat, lon, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+// ActiveNear отбирает по индексу зоны, bounds которых с запасом
+// defaultBufferM касаются точки: bounds уже включают собственный буфер
+// приближения зоны (migrations/postgis).
+func (r *IncidentPostGISRepository) ActiveNear(lat, lon, defaultBufferM float64) ([]domain.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		JOIN incident_areas ON incident_areas.incident_id = incidents.id
+		WHERE active = TRUE
+		  AND (starts_at IS NULL OR starts_at <= $3)
+		  AND (expires_at IS NULL OR expires_at > $3)
+		  AND ST_DWithin(bounds, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography, $4::float8 * 1.01 + 1)
+		  AND ST_DWithin(
+		      shape,
+		      ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography,
+		      (reach_m + COALESCE(approach_buffer_m, $4::float8)) * 1.01 + 1
+		  )
+		ORDER BY id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, lat, lon, time.Now().UTC(), defaultBufferM)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/kassse1/geo-alert-core/internal/repository"
+	"github.com/kassse1/geo-alert-core/pkg/postgres"
+)
+
+// postgisDB — testDB, на которой применены migrations/postgis.
+func postgisDB(t *testing.T) *postgres.DB {
+	t.Helper()
+
+	db := testDB(t)
+
+	var applied bool
+	if err := db.QueryRow(`SELECT to_regclass('incident_areas') IS NOT NULL`).Scan(&applied); err != nil {
+		t.Fatalf("check incident_areas: %v", err)
+	}
+	if !applied {
+		t.Skip("migrations/postgis are not applied")
+	}
+
+	return db
+}
+
+func TestIncidentPostGISRepository(t *testing.T) {
+	db := postgisDB(t)
+
+	testIncidentRepository(t, func(t *testing.T) repository.IncidentRepository {
+		truncateIncidents(t, db)
+		return repository.NewIncidentPostGISRepository(db.DB)
+	})
+
+	testSpatialIncidentRepository(t, func(t *testing.T) repository.SpatialIncidentRepository {
+		truncateIncidents(t, db)
+		return repository.NewIncidentPostGISRepository(db.DB)
+	})
+}
@@ -3,11 +3,24 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kassse1/geo-alert-core/internal/domain"
 )
 
+const incidentColumns = `id, title, severity, category, lat, lon, radius_m, geometry, line_buffer_m, approach_buffer_m, active, starts_at, expires_at, created_at, updated_by, updated_at`
+
+// IncidentChangesChannel — канал NOTIFY, в который триггер на таблице incidents
+// (migrations/004) публикует {"id": ..., "op": "INSERT|UPDATE|DELETE"}.
+const IncidentChangesChannel = "incident_changes"
+
+// bboxDegreesPerMeter — градусов широты на метр радиуса зоны с запасом 1%
+// (длина градуса меридиана на сфере ≈ 111195 м).
+const bboxDegreesPerMeter = 1.01 / 111195.0
+
 type IncidentPostgresRepository struct {
 	db *sql.DB
 }
@@ -18,11 +31,19 @@ func NewIncidentPostgresRepository(db *sql.DB) *IncidentPostgresRepository {
 
 func (r *IncidentPostgresRepository) Create(i *domain.Incident) error {
 	query := `
-		INSERT INTO incidents (title, lat, lon, radius_m)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
+		INSERT INTO incidents (
+			title, severity, category, lat, lon, radius_m, geometry, line_buffer_m,
+			approach_buffer_m, active, starts_at, expires_at, updated_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at
 	`
 
+	geometry, err := encodeGeometry(i.Geometry)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -30,30 +51,29 @@ func (r *IncidentPostgresRepository) Create(i *domain.Incident) error {
 		ctx,
 		query,
 		i.Title,
+		i.Severity,
+		i.Category,
 		i.Lat,
 		i.Lon,
 		i.RadiusM,
-	).Scan(&i.ID, &i.CreatedAt)
+		geometry,
+		i.LineBufferM,
+		nullIntPtr(i.ApproachBufferM),
+		i.Active,
+		nullTime(i.StartsAt),
+		nullTime(i.ExpiresAt),
+		i.UpdatedBy,
+	).Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt)
 }
 
 func (r *IncidentPostgresRepository) GetByID(id int64) (*domain.Incident, error) {
 	query := `
-		SELECT id, title, lat, lon, radius_m, active, created_at
+		SELECT ` + incidentColumns + `
 		FROM incidents
 		WHERE id = $1
 	`
 
-	var i domain.Incident
-
-	err := r.db.QueryRow(query, id).Scan(
-		&i.ID,
-		&i.Title,
-		&i.Lat,
-		&i.Lon,
-		&i.RadiusM,
-		&i.Active,
-		&i.CreatedAt,
-	)
+	i, err := scanIncident(r.db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -63,105 +83,381 @@ func (r *IncidentPostgresRepository) GetByID(id int64) (*domain.Incident, error)
 		return nil, err
 	}
 
-	return &i, nil
+	return i, nil
+}
+
+// Выражения колонок сортировки (ключи — domain.IncidentSort*).
+var incidentSortColumns = map[string]string{
+	domain.IncidentSortID:        "id",
+	domain.IncidentSortCreatedAt: "created_at",
+	domain.IncidentSortRadius:    "radius_m",
 }
 
-func (r *IncidentPostgresRepository) List(offset, limit int) ([]domain.Incident, error) {
+func (r *IncidentPostgresRepository) List(f domain.IncidentFilter, p domain.IncidentPage) ([]domain.Incident, error) {
+	column, ok := incidentSortColumns[p.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", p.Sort)
+	}
+
+	conditions, args := incidentConditions(f)
+
+	direction, compare := "ASC", ">"
+	if p.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	// Keyset: (поле, id) строго после курсора в порядке сортировки
+	if p.After != nil {
+		var value any
+		switch p.Sort {
+		case domain.IncidentSortCreatedAt:
+			value = p.After.CreatedAt.UTC()
+		case domain.IncidentSortRadius:
+			value = p.After.RadiusM
+		default:
+			value = p.After.ID
+		}
+		args = append(args, value, p.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, compare, len(args)-1, len(args)))
+	}
+
 	query := `
-		SELECT id, title, lat, lon, radius_m, active, created_at
+		SELECT ` + incidentColumns + `
 		FROM incidents
-		ORDER BY id
-		OFFSET $1 LIMIT $2
 	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
 
-	rows, err := r.db.Query(query, offset, limit)
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+
+	args = append(args, p.Limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+	if p.After == nil && p.Offset > 0 {
+		args = append(args, p.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var incidents []domain.Incident
+	return scanIncidents(rows)
+}
 
-	for rows.Next() {
-		var i domain.Incident
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Lat,
-			&i.Lon,
-			&i.RadiusM,
-			&i.Active,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
+func (r *IncidentPostgresRepository) Count(f domain.IncidentFilter) (int, error) {
+	conditions, args := incidentConditions(f)
+
+	query := `SELECT COUNT(*) FROM incidents`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count int
+	err := r.db.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
+
+// incidentConditions строит WHERE-условия фильтра. Активность учитывает
+// окно starts_at/expires_at, как и GetActive.
+func incidentConditions(f domain.IncidentFilter) ([]string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	add := func(condition string, arg ...any) {
+		n := make([]any, len(arg))
+		for idx, a := range arg {
+			args = append(args, a)
+			n[idx] = len(args)
 		}
-		incidents = append(incidents, i)
+		conditions = append(conditions, fmt.Sprintf(condition, n...))
+	}
+
+	if f.Active != nil {
+		active := `(active = TRUE
+			AND (starts_at IS NULL OR starts_at <= $%[1]d)
+			AND (expires_at IS NULL OR expires_at > $%[1]d))`
+		if !*f.Active {
+			active = "NOT " + active
+		}
+		add(active, time.Now().UTC())
+	}
+	if f.Severity != "" {
+		add("severity = $%d", f.Severity)
+	}
+	if f.Category != "" {
+		add("category = $%d", f.Category)
+	}
+	if !f.CreatedFrom.IsZero() {
+		add("created_at >= $%d", f.CreatedFrom.UTC())
+	}
+	if !f.CreatedTo.IsZero() {
+		add("created_at < $%d", f.CreatedTo.UTC())
+	}
+	if f.BBox != nil {
+		// Описанная окружность зоны (центр ± radius_m в градусах, с запасом
+		// 1%) пересекается с прямоугольником
+		add(`lat + radius_m * $%[5]d::float8 >= $%[1]d AND lat - radius_m * $%[5]d::float8 <= $%[2]d
+			AND lon + radius_m * $%[5]d::float8 / GREATEST(cos(radians(lat)), 0.01) >= $%[3]d
+			AND lon - radius_m * $%[5]d::float8 / GREATEST(cos(radians(lat)), 0.01) <= $%[4]d`,
+			f.BBox.MinLat, f.BBox.MaxLat, f.BBox.MinLon, f.BBox.MaxLon, bboxDegreesPerMeter)
 	}
 
-	return incidents, nil
+	return conditions, args
 }
 
 func (r *IncidentPostgresRepository) Update(i *domain.Incident) error {
 	query := `
 		UPDATE incidents
-		SET title = $1, lat = $2, lon = $3, radius_m = $4, active = $5
-		WHERE id = $6
+		SET title = $1, severity = $2, category = $3, lat = $4, lon = $5,
+		    radius_m = $6, geometry = $7, line_buffer_m = $8, approach_buffer_m = $9,
+		    active = $10, starts_at = $11, expires_at = $12, updated_by = $13,
+		    updated_at = now()
+		WHERE id = $14
 	`
 
-	_, err := r.db.Exec(
+	geometry, err := encodeGeometry(i.Geometry)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
 		query,
 		i.Title,
+		i.Severity,
+		i.Category,
 		i.Lat,
 		i.Lon,
 		i.RadiusM,
+		geometry,
+		i.LineBufferM,
+		nullIntPtr(i.ApproachBufferM),
 		i.Active,
+		nullTime(i.StartsAt),
+		nullTime(i.ExpiresAt),
+		i.UpdatedBy,
 		i.ID,
 	)
 
 	return err
 }
 
-func (r *IncidentPostgresRepository) Deactivate(id int64) error {
+func (r *IncidentPostgresRepository) Deactivate(id int64, actor string) error {
+	query := `
+		UPDATE incidents
+		SET active = FALSE, updated_by = $2, updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(query, id, actor)
+	return err
+}
+
+func (r *IncidentPostgresRepository) Activate(id int64, actor string) error {
 	query := `
 		UPDATE incidents
-		SET active = FALSE
+		SET active = TRUE, updated_by = $2, updated_at = now()
 		WHERE id = $1
 	`
 
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.Exec(query, id, actor)
 	return err
 }
 
+func (r *IncidentPostgresRepository) Delete(id int64, actor string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Автор удаления читается триггером истории (migrations/013)
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('geo_alert.actor', $1, true)`, actor); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM incidents WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (r *IncidentPostgresRepository) GetActive() ([]domain.Incident, error) {
 	query := `
-		SELECT id, title, lat, lon, radius_m, active, created_at
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		WHERE active = TRUE
+		  AND (starts_at IS NULL OR starts_at <= $1)
+		  AND (expires_at IS NULL OR expires_at > $1)
+	`
+
+	rows, err := r.db.Query(query, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+func (r *IncidentPostgresRepository) ExpireDue(now time.Time) ([]domain.Incident, error) {
+	// UPDATE ... RETURNING атомарен: при нескольких репликах каждый
+	// истёкший инцидент достанется только одной из них.
+	query := `
+		UPDATE incidents
+		SET active = FALSE, updated_by = $2, updated_at = now()
+		WHERE active = TRUE AND expires_at <= $1
+		RETURNING ` + incidentColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), domain.ActorSystem)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+func (r *IncidentPostgresRepository) StartedBetween(from, to time.Time) ([]domain.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
 		FROM incidents
 		WHERE active = TRUE
+		  AND starts_at > $1 AND starts_at <= $2
+		  AND (expires_at IS NULL OR expires_at > $2)
 	`
 
-	rows, err := r.db.Query(query)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, from.UTC(), to.UTC())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanIncidents(rows)
+}
+
+func (r *IncidentPostgresRepository) ClaimStart(id int64, startsAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO incident_start_claims (incident_id, starts_at)
+		VALUES ($1, $2)
+		ON CONFLICT (incident_id, starts_at) DO NOTHING
+	`
+
+	res, err := r.db.Exec(query, id, startsAt.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanIncident(row rowScanner) (*domain.Incident, error) {
+	var (
+		i                   domain.Incident
+		geometry            []byte
+		approachBuffer      sql.NullInt64
+		startsAt, expiresAt sql.NullTime
+	)
+
+	if err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Severity,
+		&i.Category,
+		&i.Lat,
+		&i.Lon,
+		&i.RadiusM,
+		&geometry,
+		&i.LineBufferM,
+		&approachBuffer,
+		&i.Active,
+		&startsAt,
+		&expiresAt,
+		&i.CreatedAt,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if approachBuffer.Valid {
+		buffer := int(approachBuffer.Int64)
+		i.ApproachBufferM = &buffer
+	}
+	if startsAt.Valid {
+		i.StartsAt = &startsAt.Time
+	}
+	if expiresAt.Valid {
+		i.ExpiresAt = &expiresAt.Time
+	}
+
+	if geometry != nil {
+		i.Geometry = &domain.Geometry{}
+		if err := json.Unmarshal(geometry, i.Geometry); err != nil {
+			return nil, err
+		}
+	}
+
+	return &i, nil
+}
+
+func scanIncidents(rows *sql.Rows) ([]domain.Incident, error) {
 	var incidents []domain.Incident
 
 	for rows.Next() {
-		var i domain.Incident
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Lat,
-			&i.Lon,
-			&i.RadiusM,
-			&i.Active,
-			&i.CreatedAt,
-		); err != nil {
+		i, err := scanIncident(rows)
+		if err != nil {
 			return nil, err
 		}
-		incidents = append(incidents, i)
+		incidents = append(incidents, *i)
+	}
+
+	return incidents, rows.Err()
+}
+
+// encodeGeometry возвращает GeoJSON для колонки geometry (NULL для кругов).
+func encodeGeometry(g *domain.Geometry) (any, error) {
+	if g == nil {
+		return nil, nil
 	}
+	data, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
 
-	return incidents, nil
+// nullIntPtr — NULL для незаданного значения.
+func nullIntPtr(n *int) any {
+	if n == nil {
+		return nil
+	}
+	return *n
+}
+
+// nullTime переводит время в UTC: колонки TIMESTAMP хранятся без пояса.
+func nullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
 }
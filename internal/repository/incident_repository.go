@@ -1,13 +1,44 @@
 package repository
 
-import "github.com/kassse1/geo-alert-core/internal/domain"
+import (
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
 
 
 type IncidentRepository interface {
 	Create(incident *domain.Incident) error
 	GetByID(id int64) (*domain.Incident, error)
-	List(offset, limit int) ([]domain.Incident, error)
+	List(filter domain.IncidentFilter, page domain.IncidentPage) ([]domain.Incident, error)
+	Count(filter domain.IncidentFilter) (int, error)
 	Update(incident *domain.Incident) error
-	Deactivate(id int64) error
+	Deactivate(id int64, actor string) error
+	Activate(id int64, actor string) error
+	// Delete удаляет инцидент безвозвратно; история версий сохраняется.
+	Delete(id int64, actor string) error
+	// GetActive возвращает инциденты, действующие сейчас (с учётом окна
+	// starts_at/expires_at).
 	GetActive() ([]domain.Incident, error)
+	// ExpireDue деактивирует инциденты с expires_at <= now и возвращает их.
+	ExpireDue(now time.Time) ([]domain.Incident, error)
+	// StartedBetween возвращает активные инциденты с starts_at в (from, to],
+	// ещё не истёкшие к to.
+	StartedBetween(from, to time.Time) ([]domain.Incident, error)
+	// ClaimStart атомарно отмечает начало действия инцидента с данным
+	// starts_at; true получает только первый вызов (одна реплика).
+	ClaimStart(id int64, startsAt time.Time) (bool, error)
+}
+
+// SpatialIncidentRepository дополнительно ищет зоны по точке на стороне БД.
+type SpatialIncidentRepository interface {
+	IncidentRepository
+	// ActiveContaining возвращает действующие сейчас инциденты, зона которых
+	// содержит точку (включая границу), по возрастанию id.
+	ActiveContaining(lat, lon float64) ([]domain.Incident, error)
+	// ActiveNear возвращает действующие сейчас инциденты, в зону или буфер
+	// приближения которых попадает точка (defaultBufferM — для зон без
+	// approach_buffer_m), по возрастанию id. Запас 1% и 1 м покрывает
+	// разницу способов расчёта расстояния, точную проверку делает вызывающий.
+	ActiveNear(lat, lon, defaultBufferM float64) ([]domain.Incident, error)
 }
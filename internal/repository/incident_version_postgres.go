@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// Снимок разворачивается в строку incidents через jsonb_populate_record,
+// поэтому читается тем же scanIncident, что и сами инциденты.
+const incidentVersionSelect = `
+	SELECT v.id, v.incident_id, v.version, v.op, v.actor, v.changed_at, v.diff, s.*
+	FROM incident_versions v
+	CROSS JOIN LATERAL (
+		SELECT ` + incidentColumns + `
+		FROM jsonb_populate_record(NULL::incidents, v.snapshot)
+	) s
+`
+
+type IncidentVersionPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewIncidentVersionPostgresRepository(db *sql.DB) *IncidentVersionPostgresRepository {
+	return &IncidentVersionPostgresRepository{db: db}
+}
+
+func (r *IncidentVersionPostgresRepository) List(incidentID int64) ([]domain.IncidentVersion, error) {
+	query := incidentVersionSelect + `
+		WHERE v.incident_id = $1
+		ORDER BY v.version
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []domain.IncidentVersion
+	for rows.Next() {
+		v, err := scanIncidentVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+
+	return versions, rows.Err()
+}
+
+func (r *IncidentVersionPostgresRepository) GetAt(incidentID int64, at time.Time) (*domain.IncidentVersion, error) {
+	query := incidentVersionSelect + `
+		WHERE v.incident_id = $1 AND v.changed_at <= $2
+		ORDER BY v.version DESC
+		LIMIT 1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := scanIncidentVersion(r.db.QueryRowContext(ctx, query, incidentID, at.UTC()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func scanIncidentVersion(row rowScanner) (*domain.IncidentVersion, error) {
+	var (
+		v    domain.IncidentVersion
+		diff []byte
+	)
+
+	incident, err := scanIncident(prefixScanner{
+		row: row,
+		prefix: []any{
+			&v.ID,
+			&v.IncidentID,
+			&v.Version,
+			&v.Op,
+			&v.Actor,
+			&v.ChangedAt,
+			&diff,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v.Incident = *incident
+	v.Diff = diff
+
+	return &v, nil
+}
+
+// prefixScanner читает дополнительные колонки перед колонками инцидента.
+type prefixScanner struct {
+	row    rowScanner
+	prefix []any
+}
+
+func (p prefixScanner) Scan(dest ...any) error {
+	return p.row.Scan(append(p.prefix, dest...)...)
+}
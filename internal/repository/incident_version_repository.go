@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// IncidentVersionRepository читает историю инцидентов. Записи создаются
+// триггером на таблице incidents (migrations/013), поэтому метода
+// записи здесь нет.
+type IncidentVersionRepository interface {
+	List(incidentID int64) ([]domain.IncidentVersion, error)
+	// GetAt возвращает последнюю версию на момент at; nil — инцидента тогда не было.
+	GetAt(incidentID int64, at time.Time) (*domain.IncidentVersion, error)
+}
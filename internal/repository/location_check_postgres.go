@@ -3,8 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+
 	"github.com/kassse1/geo-alert-core/internal/domain"
 )
 
@@ -29,17 +34,202 @@ func (r *LocationCheckPostgresRepository) CountUniqueUsersSince(minutes int) (in
 
 func (r *LocationCheckPostgresRepository) Save(c *domain.LocationCheck) error {
 	query := `
-		INSERT INTO location_checks (user_id, lat, lon)
-		VALUES ($1, $2, $3)
+		INSERT INTO location_checks (user_id, lat, lon, incident_ids, has_danger, distance_m, matches, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::timestamp, now()))
+		RETURNING id, checked_at
 	`
 
+	matches, err := encodeCheckMatches(c.Matches)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, query, c.UserID, c.Lat, c.Lon)
+	var distance sql.NullInt64
+	if c.HasDanger {
+		distance = sql.NullInt64{Int64: int64(c.DistanceM), Valid: true}
+	}
+
+	var checkedAt sql.NullTime
+	if !c.CheckedAt.IsZero() {
+		checkedAt = sql.NullTime{Time: c.CheckedAt, Valid: true}
+	}
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		c.UserID,
+		c.Lat,
+		c.Lon,
+		nonNil(c.IncidentIDs),
+		c.HasDanger,
+		distance,
+		matches,
+		checkedAt,
+	).Scan(&c.ID, &c.CheckedAt)
+}
+
+func (r *LocationCheckPostgresRepository) SaveBatch(checks []*domain.LocationCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	const columns = 8
+
+	values := make([]string, 0, len(checks))
+	args := make([]any, 0, len(checks)*columns)
+
+	for idx, c := range checks {
+		n := idx * columns
+		values = append(values, fmt.Sprintf(
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, COALESCE($%d::timestamp, now()))",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8,
+		))
+
+		matches, err := encodeCheckMatches(c.Matches)
+		if err != nil {
+			return err
+		}
+
+		var distance sql.NullInt64
+		if c.HasDanger {
+			distance = sql.NullInt64{Int64: int64(c.DistanceM), Valid: true}
+		}
+
+		var checkedAt sql.NullTime
+		if !c.CheckedAt.IsZero() {
+			checkedAt = sql.NullTime{Time: c.CheckedAt, Valid: true}
+		}
+
+		args = append(args,
+			c.UserID,
+			c.Lat,
+			c.Lon,
+			nonNil(c.IncidentIDs),
+			c.HasDanger,
+			distance,
+			matches,
+			checkedAt,
+		)
+	}
+
+	query := `
+		INSERT INTO location_checks (user_id, lat, lon, incident_ids, has_danger, distance_m, matches, checked_at)
+		VALUES ` + strings.Join(values, ", ")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
+func (r *LocationCheckPostgresRepository) List(f domain.LocationCheckFilter) ([]domain.LocationCheck, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.IncidentID != 0 {
+		add("$%d = ANY(incident_ids)", f.IncidentID)
+	}
+	if f.HasDanger != nil {
+		add("has_danger = $%d", *f.HasDanger)
+	}
+	if !f.From.IsZero() {
+		add("checked_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("checked_at < $%d", f.To)
+	}
+
+	query := `
+		SELECT id, user_id, lat, lon, incident_ids, has_danger, distance_m, matches, checked_at
+		FROM location_checks
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, f.Offset, f.Limit)
+	query += fmt.Sprintf(" ORDER BY checked_at DESC, id DESC OFFSET $%d LIMIT $%d", len(args)-1, len(args))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLocationChecks(rows)
+}
+
+func (r *LocationCheckPostgresRepository) LatestInArea(minutes int, box domain.BoundingBox) ([]domain.LocationCheck, error) {
+	// Сначала последняя позиция каждого пользователя, затем фильтр по области:
+	// пользователь, уже покинувший её, не должен попасть в выборку.
+	query := `
+		SELECT id, user_id, lat, lon, incident_ids, has_danger, distance_m, matches, checked_at
+		FROM (
+			SELECT DISTINCT ON (user_id)
+				id, user_id, lat, lon, incident_ids, has_danger, distance_m, matches, checked_at
+			FROM location_checks
+			WHERE checked_at >= NOW() - make_interval(mins => $1)
+			  AND user_id <> ''
+			ORDER BY user_id, checked_at DESC, id DESC
+		) latest
+		WHERE lat BETWEEN $2 AND $3
+		  AND lon BETWEEN $4 AND $5
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, minutes, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLocationChecks(rows)
+}
+
+func (r *LocationCheckPostgresRepository) LatestByUser(userID string, before time.Time) (*domain.LocationCheck, error) {
+	query := `
+		SELECT id, user_id, lat, lon, incident_ids, has_danger, distance_m, matches, checked_at
+		FROM location_checks
+		WHERE user_id = $1 AND checked_at < $2
+		ORDER BY checked_at DESC, id DESC
+		LIMIT 1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks, err := scanLocationChecks(rows)
+	if err != nil || len(checks) == 0 {
+		return nil, err
+	}
+	return &checks[0], nil
+}
+
 func (r *LocationCheckPostgresRepository) CountUniqueUsersLastMinutes(minutes int) (int, error) {
 	query := `
 		SELECT COUNT(DISTINCT user_id)
@@ -55,3 +245,67 @@ func (r *LocationCheckPostgresRepository) CountUniqueUsersLastMinutes(minutes in
 
 	return count, nil
 }
+
+func scanLocationChecks(rows *sql.Rows) ([]domain.LocationCheck, error) {
+	var checks []domain.LocationCheck
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		var (
+			c        domain.LocationCheck
+			distance sql.NullInt64
+			matches  []byte
+		)
+		if err := rows.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Lat,
+			&c.Lon,
+			types.SQLScanner(&c.IncidentIDs),
+			&c.HasDanger,
+			&distance,
+			&matches,
+			&c.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		c.DistanceM = int(distance.Int64)
+
+		var err error
+		if c.Matches, err = decodeCheckMatches(matches); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+
+	return checks, rows.Err()
+}
+
+// checkMatch — элемент колонки matches (migrations/020).
+type checkMatch struct {
+	IncidentID int64  `json:"incident_id"`
+	Status     string `json:"status"`
+	DistanceM  int    `json:"distance_m"`
+}
+
+func encodeCheckMatches(matches []domain.LocationCheckMatch) (string, error) {
+	items := make([]checkMatch, 0, len(matches))
+	for _, m := range matches {
+		items = append(items, checkMatch(m))
+	}
+	data, err := json.Marshal(items)
+	return string(data), err
+}
+
+func decodeCheckMatches(data []byte) ([]domain.LocationCheckMatch, error) {
+	var items []checkMatch
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("location check matches: %w", err)
+	}
+	matches := make([]domain.LocationCheckMatch, 0, len(items))
+	for _, m := range items {
+		matches = append(matches, domain.LocationCheckMatch(m))
+	}
+	return matches, nil
+}
@@ -1,8 +1,22 @@
 package repository
 
-import "github.com/kassse1/geo-alert-core/internal/domain"
+import (
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
 
 type LocationCheckRepository interface {
+	// Save сохраняет проверку; нулевой CheckedAt — now().
 	Save(check *domain.LocationCheck) error
+	// SaveBatch сохраняет проверки одним запросом; нулевой CheckedAt — now().
+	SaveBatch(checks []*domain.LocationCheck) error
 	CountUniqueUsersLastMinutes(minutes int) (int, error)
+	List(filter domain.LocationCheckFilter) ([]domain.LocationCheck, error)
+	// LatestInArea возвращает последнюю за minutes минут проверку каждого
+	// пользователя, если её координаты попадают в box.
+	LatestInArea(minutes int, box domain.BoundingBox) ([]domain.LocationCheck, error)
+	// LatestByUser возвращает последнюю проверку пользователя строго до
+	// before (nil, если её нет).
+	LatestByUser(userID string, before time.Time) (*domain.LocationCheck, error)
 }
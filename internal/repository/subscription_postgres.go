@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+const subscriptionColumns = `id, url, secret, event_types, incident_ids,
+	bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+	min_severity, active, created_at`
+
+type SubscriptionPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionPostgresRepository(db *sql.DB) *SubscriptionPostgresRepository {
+	return &SubscriptionPostgresRepository{db: db}
+}
+
+func (r *SubscriptionPostgresRepository) Create(s *domain.Subscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (
+			url, secret, event_types, incident_ids,
+			bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+			min_severity, active
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := append([]any{s.URL, s.Secret, nonNil(s.EventTypes), nonNil(s.IncidentIDs)}, bboxArgs(s.BBox)...)
+	args = append(args, s.MinSeverity, s.Active)
+
+	return r.db.QueryRowContext(ctx, query, args...).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *SubscriptionPostgresRepository) GetByID(id int64) (*domain.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	s, err := scanSubscription(r.db.QueryRow(query, id), pgtype.NewMap())
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *SubscriptionPostgresRepository) List() ([]domain.Subscription, error) {
+	return r.query(`
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		ORDER BY id
+	`)
+}
+
+func (r *SubscriptionPostgresRepository) GetActive() ([]domain.Subscription, error) {
+	return r.query(`
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE active = TRUE
+		ORDER BY id
+	`)
+}
+
+func (r *SubscriptionPostgresRepository) Update(s *domain.Subscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, secret = $2, event_types = $3, incident_ids = $4,
+		    bbox_min_lat = $5, bbox_min_lon = $6, bbox_max_lat = $7, bbox_max_lon = $8,
+		    min_severity = $9, active = $10
+		WHERE id = $11
+	`
+
+	args := append([]any{s.URL, s.Secret, nonNil(s.EventTypes), nonNil(s.IncidentIDs)}, bboxArgs(s.BBox)...)
+	args = append(args, s.MinSeverity, s.Active, s.ID)
+
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+func (r *SubscriptionPostgresRepository) Delete(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	return err
+}
+
+func (r *SubscriptionPostgresRepository) query(query string) ([]domain.Subscription, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []domain.Subscription
+
+	// pgtype.Map не потокобезопасен — свой на каждый запрос
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		s, err := scanSubscription(rows, types)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func scanSubscription(row rowScanner, types *pgtype.Map) (*domain.Subscription, error) {
+	var (
+		s                              domain.Subscription
+		minLat, minLon, maxLat, maxLon sql.NullFloat64
+	)
+
+	if err := row.Scan(
+		&s.ID,
+		&s.URL,
+		&s.Secret,
+		types.SQLScanner(&s.EventTypes),
+		types.SQLScanner(&s.IncidentIDs),
+		&minLat,
+		&minLon,
+		&maxLat,
+		&maxLon,
+		&s.MinSeverity,
+		&s.Active,
+		&s.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if minLat.Valid && minLon.Valid && maxLat.Valid && maxLon.Valid {
+		s.BBox = &domain.BoundingBox{
+			MinLat: minLat.Float64,
+			MinLon: minLon.Float64,
+			MaxLat: maxLat.Float64,
+			MaxLon: maxLon.Float64,
+		}
+	}
+
+	return &s, nil
+}
+
+func bboxArgs(b *domain.BoundingBox) []any {
+	if b == nil {
+		return []any{nil, nil, nil, nil}
+	}
+	return []any{b.MinLat, b.MinLon, b.MaxLat, b.MaxLon}
+}
+
+// nonNil: NOT NULL-массивы пишутся как '{}', а не NULL.
+func nonNil[T any](v []T) []T {
+	if v == nil {
+		return []T{}
+	}
+	return v
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "github.com/kassse1/geo-alert-core/internal/domain"
+
+type SubscriptionRepository interface {
+	Create(subscription *domain.Subscription) error
+	GetByID(id int64) (*domain.Subscription, error)
+	List() ([]domain.Subscription, error)
+	Update(subscription *domain.Subscription) error
+	Delete(id int64) error
+	GetActive() ([]domain.Subscription, error)
+}
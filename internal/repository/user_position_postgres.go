@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// UserPositionsChannel — канал NOTIFY с позициями пользователей:
+// {"origin": ..., "user_id": ..., "lat": ..., "lon": ...}.
+const UserPositionsChannel = "user_positions"
+
+// Postgres не принимает payload NOTIFY длиннее 8000 байт.
+const notifyPayloadLimit = 7999
+
+type userPositionPayload struct {
+	Origin string  `json:"origin"`
+	UserID string  `json:"user_id"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+}
+
+type UserPositionPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewUserPositionPostgresRepository(db *sql.DB) *UserPositionPostgresRepository {
+	return &UserPositionPostgresRepository{db: db}
+}
+
+// Publish отправляет все позиции одним запросом.
+func (r *UserPositionPostgresRepository) Publish(origin string, positions []domain.UserPosition) error {
+	payloads := make([]string, 0, len(positions))
+	for _, p := range positions {
+		data, err := json.Marshal(userPositionPayload{Origin: origin, UserID: p.UserID, Lat: p.Lat, Lon: p.Lon})
+		if err != nil {
+			return err
+		}
+		if len(data) > notifyPayloadLimit {
+			// Такой user_id не помещается в уведомление: его поток получит
+			// события только на реплике, принявшей проверку
+			continue
+		}
+		payloads = append(payloads, string(data))
+	}
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`SELECT pg_notify($1, p) FROM unnest($2::text[]) AS p`,
+		UserPositionsChannel, payloads,
+	)
+	return err
+}
+
+// ParseUserPosition разбирает уведомление из UserPositionsChannel.
+func ParseUserPosition(payload string) (string, domain.UserPosition, error) {
+	var p userPositionPayload
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return "", domain.UserPosition{}, err
+	}
+	return p.Origin, domain.UserPosition{UserID: p.UserID, Lat: p.Lat, Lon: p.Lon}, nil
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "github.com/kassse1/geo-alert-core/internal/domain"
+
+type UserPositionRepository interface {
+	// Publish рассылает позиции другим репликам через канал
+	// UserPositionsChannel; origin — идентификатор реплики-отправителя,
+	// чтобы она могла пропустить собственные уведомления.
+	Publish(origin string, positions []domain.UserPosition) error
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+type WebhookOutboxPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookOutboxPostgresRepository(db *sql.DB) *WebhookOutboxPostgresRepository {
+	return &WebhookOutboxPostgresRepository{db: db}
+}
+
+func (r *WebhookOutboxPostgresRepository) Enqueue(d *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (url, payload, subscription_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, delivery_uid::text, status, next_attempt_at, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return r.db.QueryRowContext(ctx, query, d.URL, string(d.Payload), d.SubscriptionID).
+		Scan(&d.ID, &d.UID, &d.Status, &d.NextAttemptAt, &d.CreatedAt)
+}
+
+func (r *WebhookOutboxPostgresRepository) ClaimDue(
+	limit, perSubscription int,
+	busy []int64,
+	lease time.Duration,
+) ([]domain.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id, subscription_id, next_attempt_at
+			FROM webhook_deliveries
+			WHERE status = 'pending'
+			  AND next_attempt_at <= now()
+			  AND NOT (COALESCE(subscription_id, 0) = ANY($3))
+			ORDER BY next_attempt_at
+			LIMIT $1 * 10
+			FOR UPDATE SKIP LOCKED
+		), ranked AS (
+			SELECT id, next_attempt_at,
+			       ROW_NUMBER() OVER (
+			           PARTITION BY COALESCE(subscription_id, 0)
+			           ORDER BY next_attempt_at
+			       ) AS rn
+			FROM due
+		), picked AS (
+			SELECT id
+			FROM ranked
+			WHERE rn <= $2
+			ORDER BY next_attempt_at
+			LIMIT $1
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = now() + make_interval(secs => $4)
+			FROM picked
+			WHERE d.id = picked.id
+			RETURNING d.id, d.delivery_uid, d.subscription_id, d.url, d.payload,
+			          d.status, d.attempts, d.next_attempt_at, d.created_at
+		)
+		SELECT c.id, c.delivery_uid::text, c.subscription_id, c.url, c.payload,
+		       c.status, c.attempts, c.next_attempt_at, c.created_at, COALESCE(s.secret, '')
+		FROM claimed c
+		LEFT JOIN webhook_subscriptions s ON s.id = c.subscription_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, limit, perSubscription, nonNil(busy), lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+
+	for rows.Next() {
+		var (
+			d       domain.WebhookDelivery
+			payload []byte
+		)
+		if err := rows.Scan(
+			&d.ID,
+			&d.UID,
+			&d.SubscriptionID,
+			&d.URL,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.CreatedAt,
+			&d.Secret,
+		); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookOutboxPostgresRepository) RecordAttempt(
+	d *domain.WebhookDelivery,
+	a domain.WebhookAttempt,
+	retryIn time.Duration,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)
+	`,
+		a.DeliveryID,
+		a.Attempt,
+		nullInt(a.StatusCode),
+		nullString(a.Error),
+		a.Duration.Milliseconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = now() + make_interval(secs => $3),
+		    last_status_code = $4, last_error = $5,
+		    delivered_at = CASE WHEN $1 = 'delivered' THEN now() END
+		WHERE id = $6
+	`,
+		d.Status,
+		d.Attempts,
+		retryIn.Seconds(),
+		nullInt(d.LastStatusCode),
+		nullString(d.LastError),
+		d.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func nullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
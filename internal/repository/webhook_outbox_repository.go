@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+type WebhookOutboxRepository interface {
+	Enqueue(delivery *domain.WebhookDelivery) error
+	// ClaimDue выбирает готовые к отправке записи (не больше perSubscription
+	// на одного подписчика, пропуская подписчиков из busy) и сдвигает их
+	// next_attempt_at на lease, чтобы другие воркеры их не взяли.
+	// Подписчик общего WEBHOOK_URL обозначается ID 0.
+	ClaimDue(limit, perSubscription int, busy []int64, lease time.Duration) ([]domain.WebhookDelivery, error)
+	// RecordAttempt сохраняет попытку и новое состояние записи;
+	// следующая попытка назначается через retryIn.
+	RecordAttempt(delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt, retryIn time.Duration) error
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+type ZonePresencePostgresRepository struct {
+	db *sql.DB
+}
+
+func NewZonePresencePostgresRepository(db *sql.DB) *ZonePresencePostgresRepository {
+	return &ZonePresencePostgresRepository{db: db}
+}
+
+func (r *ZonePresencePostgresRepository) Update(
+	userID string,
+	at time.Time,
+	fn func(previous []domain.ZonePresence) ([]domain.ZonePresence, []int64),
+) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// SELECT ... FOR UPDATE не блокирует пользователя без записей (первая
+	// проверка), поэтому берётся advisory-блокировка на пользователя до
+	// конца транзакции
+	if _, err := tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('user_zone_presence'), hashtext($1))`, userID,
+	); err != nil {
+		return false, err
+	}
+
+	var seenAt time.Time
+	err = tx.QueryRowContext(ctx, `SELECT seen_at FROM user_last_seen WHERE user_id = $1`, userID).Scan(&seenAt)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return false, err
+	case at.Before(seenAt):
+		return false, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id, incident_id, entered_at, last_seen_at, dwell_notified, inside
+		FROM user_zone_presence
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return false, err
+	}
+
+	var previous []domain.ZonePresence
+
+	for rows.Next() {
+		var p domain.ZonePresence
+		if err := rows.Scan(
+			&p.UserID,
+			&p.IncidentID,
+			&p.EnteredAt,
+			&p.LastSeenAt,
+			&p.DwellNotified,
+			&p.Inside,
+		); err != nil {
+			rows.Close()
+			return false, err
+		}
+		previous = append(previous, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	upsert, removed := fn(previous)
+
+	for _, p := range upsert {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO user_zone_presence (user_id, incident_id, entered_at, last_seen_at, dwell_notified, inside)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id, incident_id) DO UPDATE
+			SET entered_at = EXCLUDED.entered_at,
+			    last_seen_at = EXCLUDED.last_seen_at,
+			    dwell_notified = EXCLUDED.dwell_notified,
+			    inside = EXCLUDED.inside
+		`, userID, p.IncidentID, p.EnteredAt, p.LastSeenAt, p.DwellNotified, p.Inside)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if len(removed) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM user_zone_presence
+			WHERE user_id = $1 AND incident_id = ANY($2)
+		`, userID, removed)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_last_seen (user_id, seen_at)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET seen_at = EXCLUDED.seen_at
+	`, userID, at.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+type ZonePresenceRepository interface {
+	// Update читает записи пользователя и сохраняет изменения, вычисленные
+	// fn по ним: upsert добавляются/обновляются, зоны removed удаляются.
+	// Всё выполняется в одной транзакции под блокировкой пользователя,
+	// поэтому параллельные проверки одного пользователя (в том числе с
+	// разных реплик) видят изменения друг друга. at — время проверки: если
+	// у пользователя уже учтена более поздняя, fn не вызывается и Update
+	// возвращает false.
+	Update(userID string, at time.Time, fn func(previous []domain.ZonePresence) (upsert []domain.ZonePresence, removed []int64)) (bool, error)
+}
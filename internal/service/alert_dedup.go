@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+// AlertPublisher — получатель событий об опасностях (WebhookService или
+// обёртки над ним).
+type AlertPublisher interface {
+	Publish(eventType, userID string, incidents []domain.Incident) error
+}
+
+// DedupPublisher не пропускает повторное уведомление одной пары
+// (пользователь, инцидент) чаще раза в window. Кулдаун применяется к
+// событиям о нахождении в зоне и о приближении к ней (раздельно, чтобы
+// приближение не подавляло вход); выходы и dwell передаются как есть,
+// чтобы получатель не терял информацию о покидании зоны.
+type DedupPublisher struct {
+	next   AlertPublisher
+	store  repository.AlertCooldownRepository
+	window time.Duration
+}
+
+func NewDedupPublisher(
+	next AlertPublisher,
+	store repository.AlertCooldownRepository,
+	window time.Duration,
+) *DedupPublisher {
+	return &DedupPublisher{next: next, store: store, window: window}
+}
+
+func (d *DedupPublisher) Publish(eventType, userID string, incidents []domain.Incident) error {
+	kind := cooldownKind(eventType)
+	if kind == "" || len(incidents) == 0 {
+		return d.next.Publish(eventType, userID, incidents)
+	}
+
+	ids := make([]int64, 0, len(incidents))
+	seen := make(map[int64]bool, len(incidents))
+	for _, i := range incidents {
+		if !seen[i.ID] {
+			seen[i.ID] = true
+			ids = append(ids, i.ID)
+		}
+	}
+
+	allowedIDs, err := d.store.Acquire(kind, userID, ids, d.window)
+	if err != nil {
+		return err
+	}
+	if len(allowedIDs) == 0 {
+		return nil
+	}
+
+	allowed := make(map[int64]bool, len(allowedIDs))
+	for _, id := range allowedIDs {
+		allowed[id] = true
+	}
+
+	filtered := make([]domain.Incident, 0, len(allowedIDs))
+	for _, i := range incidents {
+		if allowed[i.ID] {
+			filtered = append(filtered, i)
+			delete(allowed, i.ID)
+		}
+	}
+
+	// Уведомление не поставлено в очередь — кулдаун снимается, иначе оно
+	// потерялось бы и не повторилось до конца окна
+	if err := d.next.Publish(eventType, userID, filtered); err != nil {
+		if releaseErr := d.store.Release(kind, userID, allowedIDs); releaseErr != nil {
+			log.Println("alert cooldown release error:", releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// RunPrune раз в окно кулдауна удаляет из хранилища истёкшие записи,
+// чтобы таблица не росла с каждой парой (пользователь, инцидент).
+func (d *DedupPublisher) RunPrune(ctx context.Context) {
+	ticker := time.NewTicker(max(d.window, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.store.Prune(d.window); err != nil {
+				log.Println("alert cooldown prune error:", err)
+			}
+		}
+	}
+}
+
+// Виды кулдауна (см. AlertCooldownRepository.Acquire).
+const (
+	cooldownInside      = "inside"
+	cooldownApproaching = "approaching"
+)
+
+// cooldownKind возвращает вид кулдауна события; "" — событие без кулдауна.
+func cooldownKind(eventType string) string {
+	switch eventType {
+	case domain.EventAlert, domain.EventEntered:
+		return cooldownInside
+	case domain.EventApproaching:
+		return cooldownApproaching
+	}
+	return ""
+}
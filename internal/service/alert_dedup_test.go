@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+// flakyPublisher отклоняет первые failures вызовов и запоминает остальные.
+type flakyPublisher struct {
+	failures  int
+	published [][]domain.Incident
+}
+
+func (p *flakyPublisher) Publish(eventType, userID string, incidents []domain.Incident) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("outbox unavailable")
+	}
+	p.published = append(p.published, incidents)
+	return nil
+}
+
+func TestDedupReleasesCooldownWhenEnqueueFails(t *testing.T) {
+	next := &flakyPublisher{failures: 1}
+	d := NewDedupPublisher(next, repository.NewAlertCooldownMemoryRepository(), time.Hour)
+	zones := []domain.Incident{{ID: 1}, {ID: 2}}
+
+	if err := d.Publish(domain.EventEntered, "truck-1", zones); err == nil {
+		t.Fatal("Publish succeeded, want enqueue error")
+	}
+
+	// Неотправленное уведомление не держит кулдаун
+	if err := d.Publish(domain.EventEntered, "truck-1", zones); err != nil {
+		t.Fatalf("retry Publish: %v", err)
+	}
+	if len(next.published) != 1 || len(next.published[0]) != 2 {
+		t.Fatalf("published %v, want both zones once", next.published)
+	}
+
+	// Отправленное — держит
+	if err := d.Publish(domain.EventEntered, "truck-1", zones); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(next.published) != 1 {
+		t.Fatalf("published %d times, want 1 within the window", len(next.published))
+	}
+}
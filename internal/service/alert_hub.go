@@ -0,0 +1,341 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+	"github.com/kassse1/geo-alert-core/pkg/postgres"
+)
+
+const (
+	// Сколько последних событий пользователя хранится для повтора
+	// после переподключения (Last-Event-ID).
+	hubReplaySize = 32
+	// Через сколько после отключения последнего подписчика состояние
+	// пользователя удаляется.
+	hubIdleTimeout      = 30 * time.Minute
+	hubSubscriberBuffer = 16
+)
+
+// StreamEvent — событие потока: актуальный набор зон, в которых (или
+// рядом с которыми) находится пользователь по последней известной позиции.
+type StreamEvent struct {
+	ID        uint64
+	UserID    string
+	Incidents []domain.IncidentMatch
+	At        time.Time
+}
+
+// HubPosition — позиция пользователя и зоны вокруг неё по результату проверки.
+type HubPosition struct {
+	domain.UserPosition
+	Incidents []domain.IncidentMatch
+}
+
+// zoneState — зона и положение пользователя относительно неё.
+type zoneState struct {
+	id     int64
+	status string
+}
+
+type userStream struct {
+	subscribers map[chan StreamEvent]struct{}
+	hasPosition bool
+	lat, lon    float64
+	current     []zoneState
+	recent      []StreamEvent
+	touchedAt   time.Time
+}
+
+// AlertHub хранит последние позиции подключённых к потоку пользователей
+// и рассылает им события, когда меняется набор зон вокруг них — из-за
+// нового положения или из-за изменения самих инцидентов. Позиции других
+// пользователей не запоминаются. Проверка может прийти на реплику, к
+// которой пользователь не подключён, поэтому позиции рассылаются
+// остальным репликам (см. SyncPositions).
+type AlertHub struct {
+	index     *IncidentIndex
+	positions repository.UserPositionRepository
+	// Идентификатор реплики в рассылке позиций
+	origin string
+
+	mu     sync.Mutex
+	nextID uint64
+	users  map[string]*userStream
+}
+
+// positions может быть nil — тогда позиции другим репликам не рассылаются.
+func NewAlertHub(index *IncidentIndex, positions repository.UserPositionRepository) *AlertHub {
+	return &AlertHub{
+		index:     index,
+		positions: positions,
+		origin:    rand.Text(),
+		users:     make(map[string]*userStream),
+	}
+}
+
+// Subscribe подключает клиента. Возвращает события после lastEventID из
+// буфера повтора и канал новых событий. Канал закрывается при отписке или
+// если клиент не успевает читать (тогда ему нужно переподключиться).
+func (h *AlertHub) Subscribe(userID string, lastEventID uint64) (<-chan StreamEvent, []StreamEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	u := h.user(userID)
+
+	var replay []StreamEvent
+	if lastEventID > 0 && lastEventID <= h.nextID {
+		for _, e := range u.recent {
+			if e.ID > lastEventID {
+				replay = append(replay, e)
+			}
+		}
+	} else if u.hasPosition {
+		// Новое подключение (или ID из прошлого запуска сервера) сразу
+		// получает текущее состояние
+		replay = []StreamEvent{h.snapshot(userID, u)}
+	}
+
+	ch := make(chan StreamEvent, hubSubscriberBuffer)
+	u.subscribers[ch] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := u.subscribers[ch]; ok {
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+		u.touchedAt = time.Now()
+	}
+
+	return ch, replay, unsubscribe
+}
+
+// ReportPositions передаёт результаты проверок подключённым пользователям
+// (событие — если набор зон вокруг них или положение относительно них
+// изменились) и рассылает позиции остальным репликам.
+func (h *AlertHub) ReportPositions(positions []HubPosition) {
+	h.mu.Lock()
+	for _, p := range positions {
+		if u, ok := h.users[p.UserID]; ok {
+			h.move(p.UserID, u, p.Lat, p.Lon, p.Incidents)
+		}
+	}
+	h.mu.Unlock()
+
+	if h.positions == nil {
+		return
+	}
+
+	remote := make([]domain.UserPosition, 0, len(positions))
+	for _, p := range positions {
+		if p.UserID != "" {
+			remote = append(remote, p.UserPosition)
+		}
+	}
+	if len(remote) == 0 {
+		return
+	}
+
+	if err := h.positions.Publish(h.origin, remote); err != nil {
+		log.Println("user position publish error:", err)
+	}
+}
+
+// SyncPositions применяет позиции из проверок на других репликах к
+// подключённым здесь пользователям. Зоны вокруг позиции пересчитываются
+// по индексу, без прогноза по движению. Позиции, отправленные, пока
+// LISTEN был разорван, теряются — поток догонит пользователя на следующей
+// проверке.
+func (h *AlertHub) SyncPositions(ctx context.Context, notifications <-chan postgres.Notification) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if n.Reconnected {
+				continue
+			}
+
+			origin, p, err := repository.ParseUserPosition(n.Payload)
+			if err != nil {
+				log.Println("user position payload error:", err)
+				continue
+			}
+			if origin == h.origin || !h.subscribed(p.UserID) {
+				continue
+			}
+
+			incidents := matchIncidents(h.index, p.Lat, p.Lon)
+
+			h.mu.Lock()
+			if u, ok := h.users[p.UserID]; ok {
+				h.move(p.UserID, u, p.Lat, p.Lon, incidents)
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// IncidentChanged пересчитывает набор зон для всех пользователей, чья
+// последняя позиция попадает (или попадала) в изменившийся инцидент или
+// его буфер приближения. Мьютекс держится только на время копирования
+// позиций и отправки событий, чтобы не задерживать проверки.
+func (h *AlertHub) IncidentChanged(change IncidentChange) {
+	type position struct {
+		userID   string
+		u        *userStream
+		lat, lon float64
+		current  []zoneState
+	}
+
+	h.mu.Lock()
+	positions := make([]position, 0, len(h.users))
+	for userID, u := range h.users {
+		if u.hasPosition {
+			positions = append(positions, position{userID, u, u.lat, u.lon, u.current})
+		}
+	}
+	h.mu.Unlock()
+
+	for _, p := range positions {
+		affected := false
+		if change.After != nil && change.After.Active {
+			_, affected = matchIncident(h.index.Distance(), *change.After, p.lat, p.lon, h.index.ApproachBuffer(*change.After))
+		}
+		if !affected && change.Before != nil {
+			affected = slices.ContainsFunc(p.current, func(z zoneState) bool { return z.id == change.Before.ID })
+		}
+		if !affected {
+			continue
+		}
+
+		incidents := matchIncidents(h.index, p.lat, p.lon)
+
+		h.mu.Lock()
+		// Если позиция успела смениться, событие по ней уже отправлено.
+		// Изменение самой зоны (границы, название) тоже отправляется клиенту
+		if h.users[p.userID] == p.u && p.u.lat == p.lat && p.u.lon == p.lon {
+			h.update(p.userID, p.u, incidents, true)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Run периодически удаляет состояние давно неактивных пользователей.
+// При остановке закрывает каналы подписчиков, чтобы открытые потоки
+// завершились и не задерживали остановку HTTP-сервера.
+func (h *AlertHub) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-ticker.C:
+			h.prune(time.Now())
+		}
+	}
+}
+
+func (h *AlertHub) prune(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userID, u := range h.users {
+		if len(u.subscribers) == 0 && now.Sub(u.touchedAt) > hubIdleTimeout {
+			delete(h.users, userID)
+		}
+	}
+}
+
+func (h *AlertHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, u := range h.users {
+		for ch := range u.subscribers {
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *AlertHub) subscribed(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.users[userID]
+	return ok
+}
+
+func (h *AlertHub) move(userID string, u *userStream, lat, lon float64, incidents []domain.IncidentMatch) {
+	u.hasPosition, u.lat, u.lon = true, lat, lon
+	h.update(userID, u, incidents, false)
+}
+
+func (h *AlertHub) user(userID string) *userStream {
+	u, ok := h.users[userID]
+	if !ok {
+		u = &userStream{
+			subscribers: make(map[chan StreamEvent]struct{}),
+			touchedAt:   time.Now(),
+		}
+		h.users[userID] = u
+	}
+	return u
+}
+
+// update публикует событие, если набор зон или положение относительно них
+// изменились, или force. Изменение расстояния до зоны внутри буфера
+// приближения само по себе события не порождает.
+func (h *AlertHub) update(userID string, u *userStream, incidents []domain.IncidentMatch, force bool) {
+	zones := make([]zoneState, 0, len(incidents))
+	for _, m := range incidents {
+		zones = append(zones, zoneState{id: m.ID, status: m.Status})
+	}
+	slices.SortFunc(zones, func(a, b zoneState) int { return cmp.Compare(a.id, b.id) })
+
+	if !force && slices.Equal(zones, u.current) && u.recent != nil {
+		return
+	}
+	u.current = zones
+
+	h.nextID++
+	e := StreamEvent{ID: h.nextID, UserID: userID, Incidents: incidents, At: time.Now().UTC()}
+
+	u.recent = append(u.recent, e)
+	if len(u.recent) > hubReplaySize {
+		u.recent = u.recent[len(u.recent)-hubReplaySize:]
+	}
+
+	for ch := range u.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *AlertHub) snapshot(userID string, u *userStream) StreamEvent {
+	if len(u.recent) > 0 {
+		return u.recent[len(u.recent)-1]
+	}
+	return StreamEvent{UserID: userID, Incidents: matchIncidents(h.index, u.lat, u.lon), At: time.Now().UTC()}
+}
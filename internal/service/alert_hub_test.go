@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/pkg/postgres"
+)
+
+// relayPositions доставляет опубликованные позиции всем репликам, как
+// NOTIFY: в том числе отправителю.
+type relayPositions struct {
+	listeners []chan postgres.Notification
+}
+
+func (r *relayPositions) Publish(origin string, positions []domain.UserPosition) error {
+	for _, p := range positions {
+		payload, err := json.Marshal(map[string]any{"origin": origin, "user_id": p.UserID, "lat": p.Lat, "lon": p.Lon})
+		if err != nil {
+			return err
+		}
+		for _, ch := range r.listeners {
+			ch <- postgres.Notification{Channel: "user_positions", Payload: string(payload)}
+		}
+	}
+	return nil
+}
+
+func receive(t *testing.T, events <-chan StreamEvent) StreamEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no stream event")
+		return StreamEvent{}
+	}
+}
+
+func TestAlertHubDeliversPositionsFromOtherReplica(t *testing.T) {
+	index := routeIndex(domain.Incident{Title: "Fire", Lat: 43.24, Lon: 76.89, RadiusM: 100})
+
+	relay := &relayPositions{}
+	hubs := make([]*AlertHub, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for k := range hubs {
+		ch := make(chan postgres.Notification, 16)
+		relay.listeners = append(relay.listeners, ch)
+		hubs[k] = NewAlertHub(index, relay)
+		go hubs[k].SyncPositions(ctx, ch)
+	}
+
+	// Пользователь подключён к реплике 1, проверка пришла на реплику 0
+	events, replay, unsubscribe := hubs[1].Subscribe("truck-1", 0)
+	defer unsubscribe()
+	if len(replay) != 0 {
+		t.Fatalf("replay before any position: %+v", replay)
+	}
+
+	inside := matchIncidents(index, 43.24, 76.89)
+	hubs[0].ReportPositions([]HubPosition{
+		{UserPosition: domain.UserPosition{UserID: "truck-1", Lat: 43.24, Lon: 76.89}, Incidents: inside},
+		{UserPosition: domain.UserPosition{UserID: "truck-2", Lat: 43.24, Lon: 76.89}, Incidents: inside},
+	})
+
+	e := receive(t, events)
+	if e.UserID != "truck-1" || len(e.Incidents) != 1 || !e.Incidents[0].Inside() {
+		t.Fatalf("event = %+v, want truck-1 inside incident 1", e)
+	}
+
+	// Собственное уведомление реплика 0 пропускает, а позиции
+	// неподключённых пользователей нигде не запоминаются
+	time.Sleep(50 * time.Millisecond)
+	for k, h := range hubs {
+		h.mu.Lock()
+		_, tracked0 := h.users["truck-1"]
+		_, tracked2 := h.users["truck-2"]
+		h.mu.Unlock()
+		if tracked2 || (k == 0 && tracked0) {
+			t.Errorf("hub %d tracks unsubscribed users", k)
+		}
+	}
+}
+
+func TestAlertHubIncidentChangedUpdatesSubscribers(t *testing.T) {
+	index := routeIndex()
+	hub := NewAlertHub(index, nil)
+
+	events, _, unsubscribe := hub.Subscribe("truck-1", 0)
+	defer unsubscribe()
+
+	hub.ReportPositions([]HubPosition{{UserPosition: domain.UserPosition{UserID: "truck-1", Lat: 43.24, Lon: 76.89}}})
+	if e := receive(t, events); len(e.Incidents) != 0 {
+		t.Fatalf("first event = %+v, want no incidents", e)
+	}
+
+	fire := domain.Incident{ID: 7, Title: "Fire", Lat: 43.24, Lon: 76.89, RadiusM: 100, Active: true}
+	applyBoundingCircle(&fire, Haversine{})
+	index.Upsert(fire)
+	hub.IncidentChanged(IncidentChange{After: &fire})
+
+	e := receive(t, events)
+	if len(e.Incidents) != 1 || e.Incidents[0].ID != 7 {
+		t.Fatalf("event after change = %+v, want incident 7", e)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import "math"
+
+// DistanceCalculator — расстояние между двумя точками в метрах. Проверка
+// координат, поиск ближайших зон, маршруты и прогноз используют один
+// калькулятор — тот, что задан в IncidentIndex.
+type DistanceCalculator interface {
+	Distance(lat1, lon1, lat2, lon2 float64) float64
+}
+
+// Haversine — сфера радиусом 6371 км. Быстрая, но ошибается до ~0.5%
+// (до сотен метров на зонах радиусом в десятки километров).
+type Haversine struct{}
+
+func (Haversine) Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	return DistanceMeters(lat1, lon1, lat2, lon2)
+}
+
+// Эллипсоид WGS-84.
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+
+	vincentyMaxIterations = 200
+	vincentyTolerance     = 1e-12
+)
+
+// Vincenty — обратная задача Винсенти на эллипсоиде WGS-84 (точность —
+// доли миллиметра). Для почти антиподальных точек, где итерации не
+// сходятся, используется сферическая формула.
+type Vincenty struct{}
+
+func (Vincenty) Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	dLon := toRadians(lon2 - lon1)
+
+	// Приведённые широты
+	sinU1, cosU1 := math.Sincos(math.Atan((1 - wgs84F) * math.Tan(toRadians(lat1))))
+	sinU2, cosU2 := math.Sincos(math.Atan((1 - wgs84F) * math.Tan(toRadians(lat2))))
+
+	lambda := dLon
+	for range vincentyMaxIterations {
+		sinLambda, cosLambda := math.Sincos(lambda)
+
+		sinSigma := math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0 // совпадающие точки
+		}
+		cosSigma := sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma := math.Atan2(sinSigma, cosSigma)
+
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha := 1 - sinAlpha*sinAlpha
+
+		// На экваторе cosSqAlpha = 0
+		var cos2SigmaM float64
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+
+		c := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		prev := lambda
+		lambda = dLon + (1-c)*wgs84F*sinAlpha*
+			(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+		if math.Abs(lambda-prev) > vincentyTolerance {
+			continue
+		}
+
+		uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+		a := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+		b := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+		deltaSigma := b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+		return wgs84B * a * (sigma - deltaSigma)
+	}
+
+	return DistanceMeters(lat1, lon1, lat2, lon2)
+}
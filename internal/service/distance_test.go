@@ -0,0 +1,60 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+// Эталонные геодезические расстояния на эллипсоиде WGS-84.
+var geodesicCases = []struct {
+	name                   string
+	lat1, lon1, lat2, lon2 float64
+	wantM                  float64
+}{
+	// Пример из статьи Винсенти (1975) и Geoscience Australia
+	{"Flinders Peak - Buninyong", -37.95103341666667, 144.42486788888888, -37.65282113888889, 143.92649552777777, 54972.271},
+	{"1 degree along equator", 0, 0, 0, 1, 111319.491},
+	{"1 degree along meridian", 0, 0, 1, 0, 110574.389},
+	{"coincident points", 43.24, 76.89, 43.24, 76.89, 0},
+}
+
+func TestVincentyReference(t *testing.T) {
+	for _, c := range geodesicCases {
+		got := Vincenty{}.Distance(c.lat1, c.lon1, c.lat2, c.lon2)
+		if math.Abs(got-c.wantM) > 0.001 {
+			t.Errorf("%s: Vincenty = %.4f m, want %.3f m", c.name, got, c.wantM)
+		}
+	}
+}
+
+func TestVincentyNearlyAntipodalFallback(t *testing.T) {
+	// Для этой пары итерации Винсенти не сходятся
+	lat1, lon1, lat2, lon2 := 0.0, 0.0, 0.5, 179.7
+
+	got := Vincenty{}.Distance(lat1, lon1, lat2, lon2)
+	if want := DistanceMeters(lat1, lon1, lat2, lon2); got != want {
+		t.Fatalf("Vincenty = %.3f m, want spherical fallback %.3f m", got, want)
+	}
+	if math.IsNaN(got) || got < 19.9e6 || got > 20.0e6 {
+		t.Fatalf("Vincenty = %.3f m, want about half of the meridian", got)
+	}
+}
+
+func TestHaversineTolerance(t *testing.T) {
+	// Погрешность сферы достигает ~0.56% на меридиане у экватора
+	const tolerance = 0.006
+
+	for _, c := range geodesicCases {
+		got := Haversine{}.Distance(c.lat1, c.lon1, c.lat2, c.lon2)
+		if c.wantM == 0 {
+			if got != 0 {
+				t.Errorf("%s: Haversine = %.3f m, want 0", c.name, got)
+			}
+			continue
+		}
+		if rel := math.Abs(got-c.wantM) / c.wantM; rel > tolerance {
+			t.Errorf("%s: Haversine = %.3f m, want %.3f m within %.1f%% (off by %.2f%%)",
+				c.name, got, c.wantM, tolerance*100, rel*100)
+		}
+	}
+}
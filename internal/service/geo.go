@@ -1,6 +1,10 @@
 package service
 
-import "math"
+import (
+	"math"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
 
 const earthRadiusMeters = 6371000
 
@@ -16,6 +20,261 @@ func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadiusMeters * c
 }
 
+// IncidentContains проверяет, находится ли точка внутри зоны инцидента;
+// расстояния до круга и до линии измеряет dist.
+// Линейная зона — полоса шириной LineBufferM по обе стороны от линии.
+func IncidentContains(dist DistanceCalculator, i domain.Incident, lat, lon float64) bool {
+	if i.Geometry != nil && i.Geometry.Type == domain.GeometryLineString {
+		_, _, d := nearestOnLine(dist, i.Geometry.Line, lat, lon)
+		return d <= float64(i.LineBufferM)
+	}
+	if i.Geometry != nil {
+		return GeometryContains(*i.Geometry, lat, lon)
+	}
+	return dist.Distance(lat, lon, i.Lat, i.Lon) <= float64(i.RadiusM)
+}
+
+// GeometryContains проверяет попадание точки в полигоны геометрии; для
+// линии без ширины всегда false (см. IncidentContains).
+func GeometryContains(g domain.Geometry, lat, lon float64) bool {
+	for _, p := range g.Polygons {
+		if polygonContains(p, lat, lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// BoundingCircle возвращает центр и радиус окружности, покрывающей геометрию
+// (для линии — саму линию, без ширины полосы).
+func BoundingCircle(dist DistanceCalculator, g domain.Geometry) (lat, lon float64, radiusM int) {
+	points := append([]domain.Point(nil), g.Line...)
+	for _, p := range g.Polygons {
+		points = append(points, p[0]...)
+	}
+
+	minLat, minLon := math.Inf(1), math.Inf(1)
+	maxLat, maxLon := math.Inf(-1), math.Inf(-1)
+
+	for _, pt := range points {
+		minLat = math.Min(minLat, pt.Lat())
+		maxLat = math.Max(maxLat, pt.Lat())
+		minLon = math.Min(minLon, pt.Lon())
+		maxLon = math.Max(maxLon, pt.Lon())
+	}
+
+	lat = (minLat + maxLat) / 2
+	lon = (minLon + maxLon) / 2
+
+	var maxDist float64
+	for _, pt := range points {
+		maxDist = math.Max(maxDist, dist.Distance(lat, lon, pt.Lat(), pt.Lon()))
+	}
+
+	return lat, lon, int(math.Ceil(maxDist))
+}
+
+// polygonContains: точка внутри внешнего контура и вне всех дыр. Граница
+// (в том числе граница дыры) относится к зоне, как и у круга и линии.
+func polygonContains(p domain.Polygon, lat, lon float64) bool {
+	if len(p) == 0 {
+		return false
+	}
+	if onRing(p[0], lat, lon) {
+		return true
+	}
+	if !ringContains(p[0], lat, lon) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if onRing(hole, lat, lon) {
+			return true
+		}
+		if ringContains(hole, lat, lon) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains — ray casting в координатах lon/lat; для точек на границе
+// результат зависит от стороны, поэтому их отдельно проверяет onRing.
+func ringContains(r domain.Ring, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat() > lat) != (b.Lat() > lat) {
+			x := (b.Lon()-a.Lon())*(lat-a.Lat())/(b.Lat()-a.Lat()) + a.Lon()
+			if lon < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// ringEpsilon — допуск попадания на ребро, в градусах (~0.1 мм).
+const ringEpsilon = 1e-9
+
+// onRing проверяет, лежит ли точка на одном из рёбер контура.
+func onRing(r domain.Ring, lat, lon float64) bool {
+	for k := 1; k < len(r); k++ {
+		a, b := r[k-1], r[k]
+		if lon < math.Min(a.Lon(), b.Lon())-ringEpsilon || lon > math.Max(a.Lon(), b.Lon())+ringEpsilon ||
+			lat < math.Min(a.Lat(), b.Lat())-ringEpsilon || lat > math.Max(a.Lat(), b.Lat())+ringEpsilon {
+			continue
+		}
+		dx, dy := b.Lon()-a.Lon(), b.Lat()-a.Lat()
+		cross := dx*(lat-a.Lat()) - dy*(lon-a.Lon())
+		if math.Abs(cross) <= ringEpsilon*math.Hypot(dx, dy) {
+			return true
+		}
+	}
+	return false
+}
+
+// BearingDegrees — начальный азимут из первой точки во вторую
+// (0 — север, 90 — восток), в диапазоне [0, 360).
+func BearingDegrees(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1R, lat2R := toRadians(lat1), toRadians(lat2)
+	dLon := toRadians(lon2 - lon1)
+
+	y := math.Sin(dLon) * math.Cos(lat2R)
+	x := math.Cos(lat1R)*math.Sin(lat2R) - math.Sin(lat1R)*math.Cos(lat2R)*math.Cos(dLon)
+
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination — точка на расстоянии distanceM по азимуту bearingDeg.
+func Destination(lat, lon, bearingDeg, distanceM float64) (float64, float64) {
+	ang := distanceM / earthRadiusMeters
+	brg := toRadians(bearingDeg)
+	lat1R, lon1R := toRadians(lat), toRadians(lon)
+
+	lat2R := math.Asin(math.Sin(lat1R)*math.Cos(ang) + math.Cos(lat1R)*math.Sin(ang)*math.Cos(brg))
+	lon2R := lon1R + math.Atan2(math.Sin(brg)*math.Sin(ang)*math.Cos(lat1R), math.Cos(ang)-math.Sin(lat1R)*math.Sin(lat2R))
+
+	return toDegrees(lat2R), math.Mod(toDegrees(lon2R)+540, 360) - 180
+}
+
+// NearestPoint возвращает ближайшую к (lat, lon) точку зоны и расстояние
+// до её границы. Для точки внутри зоны расстояние 0, а ближайшей точкой
+// считается сама точка. Точка ищется на сфере, расстояние измеряет dist.
+func NearestPoint(dist DistanceCalculator, i domain.Incident, lat, lon float64) (nLat, nLon, distanceM float64) {
+	if IncidentContains(dist, i, lat, lon) {
+		return lat, lon, 0
+	}
+
+	if i.Geometry == nil {
+		d := dist.Distance(lat, lon, i.Lat, i.Lon)
+		bearing := BearingDegrees(lat, lon, i.Lat, i.Lon)
+		nLat, nLon = Destination(lat, lon, bearing, d-float64(i.RadiusM))
+		return nLat, nLon, d - float64(i.RadiusM)
+	}
+
+	if i.Geometry.Type == domain.GeometryLineString {
+		// Граница полосы — на расстоянии LineBufferM от ближайшей точки линии
+		la, lo, d := nearestOnLine(dist, i.Geometry.Line, lat, lon)
+		nLat, nLon = Destination(la, lo, BearingDegrees(la, lo, lat, lon), float64(i.LineBufferM))
+		return nLat, nLon, d - float64(i.LineBufferM)
+	}
+
+	distanceM = math.Inf(1)
+	for _, p := range i.Geometry.Polygons {
+		for _, ring := range p {
+			for k := 1; k < len(ring); k++ {
+				la, lo, d := nearestOnSegment(dist, lat, lon, ring[k-1], ring[k])
+				if d < distanceM {
+					nLat, nLon, distanceM = la, lo, d
+				}
+			}
+		}
+	}
+	return nLat, nLon, distanceM
+}
+
+// nearestOnLine — ближайшая к точке точка ломаной и расстояние до неё.
+func nearestOnLine(dist DistanceCalculator, line domain.LineString, lat, lon float64) (nLat, nLon, distanceM float64) {
+	distanceM = math.Inf(1)
+	for k := 1; k < len(line); k++ {
+		la, lo, d := nearestOnSegment(dist, lat, lon, line[k-1], line[k])
+		if d < distanceM {
+			nLat, nLon, distanceM = la, lo, d
+		}
+	}
+	return nLat, nLon, distanceM
+}
+
+// nearestOnSegment — ближайшая точка дуги большого круга a→b: по
+// поперечному (cross-track) и продольному (along-track) расстояниям на
+// сфере. Расстояние до найденной точки измеряет dist.
+func nearestOnSegment(dist DistanceCalculator, lat, lon float64, a, b domain.Point) (nLat, nLon, distanceM float64) {
+	dAP := DistanceMeters(a.Lat(), a.Lon(), lat, lon)
+	dAB := DistanceMeters(a.Lat(), a.Lon(), b.Lat(), b.Lon())
+	if dAB == 0 {
+		return a.Lat(), a.Lon(), dist.Distance(a.Lat(), a.Lon(), lat, lon)
+	}
+
+	brgAP := toRadians(BearingDegrees(a.Lat(), a.Lon(), lat, lon))
+	brgAB := toRadians(BearingDegrees(a.Lat(), a.Lon(), b.Lat(), b.Lon()))
+	angAP := dAP / earthRadiusMeters
+
+	// Точка «позади» начала отрезка
+	if math.Cos(brgAP-brgAB) <= 0 {
+		return a.Lat(), a.Lon(), dist.Distance(a.Lat(), a.Lon(), lat, lon)
+	}
+
+	angXT := math.Asin(math.Sin(angAP) * math.Sin(brgAP-brgAB))
+	along := math.Acos(math.Max(-1, math.Min(1, math.Cos(angAP)/math.Cos(angXT)))) * earthRadiusMeters
+
+	if along >= dAB {
+		return b.Lat(), b.Lon(), dist.Distance(b.Lat(), b.Lon(), lat, lon)
+	}
+
+	nLat, nLon = Destination(a.Lat(), a.Lon(), toDegrees(brgAB), along)
+	return nLat, nLon, dist.Distance(nLat, nLon, lat, lon)
+}
+
 func toRadians(deg float64) float64 {
 	return deg * math.Pi / 180
 }
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Единичные векторы точек на сфере — для пересечений дуг большого круга
+// с границами зон (см. route.go).
+type vec3 [3]float64
+
+func unitVector(lat, lon float64) vec3 {
+	latR, lonR := toRadians(lat), toRadians(lon)
+	return vec3{math.Cos(latR) * math.Cos(lonR), math.Cos(latR) * math.Sin(lonR), math.Sin(latR)}
+}
+
+// tangentVector — единичный вектор направления bearingDeg в точке lat/lon.
+func tangentVector(lat, lon, bearingDeg float64) vec3 {
+	latR, lonR, brg := toRadians(lat), toRadians(lon), toRadians(bearingDeg)
+	north := vec3{-math.Sin(latR) * math.Cos(lonR), -math.Sin(latR) * math.Sin(lonR), math.Cos(latR)}
+	east := vec3{-math.Sin(lonR), math.Cos(lonR), 0}
+	return north.scale(math.Cos(brg)).add(east.scale(math.Sin(brg)))
+}
+
+func (v vec3) dot(w vec3) float64 { return v[0]*w[0] + v[1]*w[1] + v[2]*w[2] }
+
+func (v vec3) cross(w vec3) vec3 {
+	return vec3{v[1]*w[2] - v[2]*w[1], v[2]*w[0] - v[0]*w[2], v[0]*w[1] - v[1]*w[0]}
+}
+
+func (v vec3) add(w vec3) vec3 { return vec3{v[0] + w[0], v[1] + w[1], v[2] + w[2]} }
+
+func (v vec3) scale(k float64) vec3 { return vec3{v[0] * k, v[1] * k, v[2] * k} }
+
+// normalize возвращает false для нулевого вектора.
+func (v vec3) normalize() (vec3, bool) {
+	n := math.Sqrt(v.dot(v))
+	if n == 0 {
+		return vec3{}, false
+	}
+	return v.scale(1 / n), true
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+func TestGeometryContainsPolygonWithHoles(t *testing.T) {
+	// Квадрат 0..10 с дырой 4..6 и отдельный квадрат 20..22 (MultiPolygon)
+	g := domain.Geometry{
+		Type: domain.GeometryMultiPolygon,
+		Polygons: []domain.Polygon{
+			{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}},
+			},
+			{
+				{{20, 20}, {22, 20}, {22, 22}, {20, 22}, {20, 20}},
+			},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		lon, lat float64
+		want     bool
+	}{
+		{"shell", 2, 2, true},
+		{"shell near hole", 3.999, 5, true},
+		{"hole", 5, 5, false},
+		{"hole near edge", 4.001, 5.999, false},
+		{"outside", 11, 5, false},
+		{"second polygon", 21, 21, true},
+		{"between polygons", 15, 15, false},
+		{"shell left edge", 0, 5, true},
+		{"shell right edge", 10, 5, true},
+		{"shell top edge", 5, 10, true},
+		{"shell bottom edge", 5, 0, true},
+		{"shell vertex", 10, 10, true},
+		{"hole left edge", 4, 5, true},
+		{"hole right edge", 6, 5, true},
+		{"hole top edge", 5, 6, true},
+		{"hole vertex", 6, 6, true},
+		{"just outside shell", 10.000001, 5, false},
+	}
+
+	for _, c := range cases {
+		if got := GeometryContains(g, c.lat, c.lon); got != c.want {
+			t.Errorf("%s (%v, %v): GeometryContains = %v, want %v", c.name, c.lon, c.lat, got, c.want)
+		}
+	}
+}
+
+func TestIncidentContainsPolygonWithHole(t *testing.T) {
+	i := domain.Incident{Geometry: &domain.Geometry{
+		Type: domain.GeometryPolygon,
+		Polygons: []domain.Polygon{{
+			{{76.80, 43.20}, {76.90, 43.20}, {76.90, 43.30}, {76.80, 43.30}, {76.80, 43.20}},
+			{{76.84, 43.24}, {76.86, 43.24}, {76.86, 43.26}, {76.84, 43.26}, {76.84, 43.24}},
+		}},
+	}}
+
+	if !IncidentContains(Haversine{}, i, 43.22, 76.82) {
+		t.Error("point in shell is not contained")
+	}
+	if IncidentContains(Haversine{}, i, 43.25, 76.85) {
+		t.Error("point in hole is contained")
+	}
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+const (
+	// Размер ячейки сетки в градусах (~5.5 км по широте).
+	indexCellDeg = 0.05
+	// Зоны, покрывающие больше ячеек, хранятся в общем списке.
+	indexMaxCellsPerIncident = 4096
+	metersPerDegree          = earthRadiusMeters * math.Pi / 180
+)
+
+type cellKey struct {
+	lat, lon int
+}
+
+// IncidentIndex — in-memory сеточный индекс активных инцидентов.
+// Каждый инцидент регистрируется во всех ячейках, которые пересекает
+// его описанная окружность, расширенная на буфер приближения, поэтому
+// проверка точки затрагивает только инциденты одной ячейки.
+type IncidentIndex struct {
+	approachBufferM int
+	distance        DistanceCalculator
+
+	mu        sync.RWMutex
+	incidents map[int64]domain.Incident
+	cells     map[cellKey]map[int64]struct{}
+	keys      map[int64][]cellKey
+	wide      map[int64]struct{}
+}
+
+// approachBufferM — буфер приближения для зон, у которых он не задан;
+// distance — способ измерения расстояний для всех проверок по индексу.
+func NewIncidentIndex(approachBufferM int, distance DistanceCalculator) *IncidentIndex {
+	return &IncidentIndex{
+		approachBufferM: approachBufferM,
+		distance:        distance,
+		incidents:       make(map[int64]domain.Incident),
+		cells:           make(map[cellKey]map[int64]struct{}),
+		keys:            make(map[int64][]cellKey),
+		wide:            make(map[int64]struct{}),
+	}
+}
+
+// Load полностью заменяет содержимое индекса. Зоны вне окна действия
+// (StartsAt/ExpiresAt) в индекс не попадают.
+func (x *IncidentIndex) Load(incidents []domain.Incident) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	now := time.Now()
+
+	x.incidents = make(map[int64]domain.Incident, len(incidents))
+	x.cells = make(map[cellKey]map[int64]struct{})
+	x.keys = make(map[int64][]cellKey, len(incidents))
+	x.wide = make(map[int64]struct{})
+
+	for _, i := range incidents {
+		if i.ActiveAt(now) {
+			x.insert(i)
+		}
+	}
+}
+
+// Upsert добавляет или заменяет инцидент; неактивные и не действующие
+// сейчас удаляются из индекса.
+func (x *IncidentIndex) Upsert(i domain.Incident) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(i.ID)
+	if i.ActiveAt(time.Now()) {
+		x.insert(i)
+	}
+}
+
+func (x *IncidentIndex) Remove(id int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+}
+
+func (x *IncidentIndex) Get(id int64) (domain.Incident, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	i, ok := x.incidents[id]
+	return i, ok
+}
+
+// All возвращает копию всех инцидентов индекса.
+func (x *IncidentIndex) All() []domain.Incident {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	result := make([]domain.Incident, 0, len(x.incidents))
+	for _, i := range x.incidents {
+		result = append(result, i)
+	}
+	return result
+}
+
+// ApproachBuffer — ширина буфера приближения зоны в метрах.
+func (x *IncidentIndex) ApproachBuffer(i domain.Incident) float64 {
+	if i.ApproachBufferM != nil {
+		return float64(*i.ApproachBufferM)
+	}
+	return float64(x.approachBufferM)
+}
+
+// Distance — калькулятор расстояний, с которым сверяются зоны индекса.
+func (x *IncidentIndex) Distance() DistanceCalculator {
+	return x.distance
+}
+
+func (x *IncidentIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.incidents)
+}
+
+// Candidates возвращает инциденты, чья описанная окружность вместе с
+// буфером приближения может содержать точку. Точная проверка —
+// IncidentContains и NearestPoint.
+func (x *IncidentIndex) Candidates(lat, lon float64) []domain.Incident {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	ids := x.cells[cellOf(lat, lon)]
+
+	result := make([]domain.Incident, 0, len(ids)+len(x.wide))
+	for id := range ids {
+		result = append(result, x.incidents[id])
+	}
+	for id := range x.wide {
+		result = append(result, x.incidents[id])
+	}
+
+	return result
+}
+
+// Near возвращает инциденты, чья описанная окружность может оказаться
+// ближе radiusM к точке. Если область поиска слишком велика для сетки,
+// возвращаются все инциденты.
+func (x *IncidentIndex) Near(lat, lon, radiusM float64) []domain.Incident {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	minLat, minLon, maxLat, maxLon := circleBounds(lat, lon, radiusM)
+	from, to := cellOf(minLat, minLon), cellOf(maxLat, maxLon)
+
+	if (to.lat-from.lat+1)*(to.lon-from.lon+1) > indexMaxCellsPerIncident {
+		result := make([]domain.Incident, 0, len(x.incidents))
+		for _, i := range x.incidents {
+			result = append(result, i)
+		}
+		return result
+	}
+
+	seen := make(map[int64]struct{})
+	result := make([]domain.Incident, 0, len(x.wide))
+	for la := from.lat; la <= to.lat; la++ {
+		for lo := from.lon; lo <= to.lon; lo++ {
+			for id := range x.cells[cellKey{lat: la, lon: lo}] {
+				if _, ok := seen[id]; !ok {
+					seen[id] = struct{}{}
+					result = append(result, x.incidents[id])
+				}
+			}
+		}
+	}
+	for id := range x.wide {
+		result = append(result, x.incidents[id])
+	}
+
+	return result
+}
+
+func (x *IncidentIndex) insert(i domain.Incident) {
+	x.incidents[i.ID] = i
+
+	minLat, minLon, maxLat, maxLon := circleBounds(i.Lat, i.Lon, float64(i.RadiusM)+x.ApproachBuffer(i))
+	from, to := cellOf(minLat, minLon), cellOf(maxLat, maxLon)
+
+	n := (to.lat - from.lat + 1) * (to.lon - from.lon + 1)
+	if n > indexMaxCellsPerIncident {
+		x.wide[i.ID] = struct{}{}
+		return
+	}
+
+	keys := make([]cellKey, 0, n)
+	for la := from.lat; la <= to.lat; la++ {
+		for lo := from.lon; lo <= to.lon; lo++ {
+			k := cellKey{lat: la, lon: lo}
+			if x.cells[k] == nil {
+				x.cells[k] = make(map[int64]struct{})
+			}
+			x.cells[k][i.ID] = struct{}{}
+			keys = append(keys, k)
+		}
+	}
+	x.keys[i.ID] = keys
+}
+
+func (x *IncidentIndex) remove(id int64) {
+	for _, k := range x.keys[id] {
+		delete(x.cells[k], id)
+		if len(x.cells[k]) == 0 {
+			delete(x.cells, k)
+		}
+	}
+	delete(x.keys, id)
+	delete(x.wide, id)
+	delete(x.incidents, id)
+}
+
+func cellOf(lat, lon float64) cellKey {
+	return cellKey{
+		lat: int(math.Floor(lat / indexCellDeg)),
+		lon: int(math.Floor(lon / indexCellDeg)),
+	}
+}
+
+// circleBounds — ограничивающий прямоугольник окружности в градусах
+// (с небольшим запасом на погрешность сферической модели).
+func circleBounds(lat, lon, radiusM float64) (minLat, minLon, maxLat, maxLon float64) {
+	radiusM = radiusM*1.01 + 1
+
+	dLat := radiusM / metersPerDegree
+
+	cosLat := math.Cos(toRadians(math.Min(math.Abs(lat)+dLat, 89.9)))
+	dLon := radiusM / (metersPerDegree * cosLat)
+
+	return lat - dLat, math.Max(lon-dLon, -180), lat + dLat, math.Min(lon+dLon, 180)
+}
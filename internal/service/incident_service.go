@@ -1,24 +1,226 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/kassse1/geo-alert-core/internal/domain"
 	"github.com/kassse1/geo-alert-core/internal/repository"
+	"github.com/kassse1/geo-alert-core/pkg/postgres"
 )
 
+var (
+	ErrIncidentNotFound = errors.New("incident not found")
+	// ErrIncidentExpired — зону с истёкшим expires_at нельзя активировать,
+	// пока срок не продлён: иначе её сразу деактивирует RunSchedule.
+	ErrIncidentExpired = errors.New("incident expired")
+)
+
+// IncidentChange — изменение активной зоны в индексе. Before=nil означает,
+// что зона появилась, After=nil — что она удалена или деактивирована.
+// Local=true — изменение сделано через этот экземпляр сервиса, а не
+// получено от другой реплики или при перезагрузке индекса.
+type IncidentChange struct {
+	Before *domain.Incident
+	After  *domain.Incident
+	Local  bool
+}
+
 type IncidentService struct {
-	repo      repository.IncidentRepository
-	checkRepo repository.LocationCheckRepository
+	repo        repository.IncidentRepository
+	checkRepo   repository.LocationCheckRepository
+	versionRepo repository.IncidentVersionRepository
+	index       *IncidentIndex
+
+	mu        sync.Mutex
+	observers []func(IncidentChange)
 }
 
 func NewIncidentService(
 	repo repository.IncidentRepository,
 	checkRepo repository.LocationCheckRepository,
+	versionRepo repository.IncidentVersionRepository,
+	index *IncidentIndex,
 ) *IncidentService {
 	return &IncidentService{
-		repo:      repo,
-		checkRepo: checkRepo,
+		repo:        repo,
+		checkRepo:   checkRepo,
+		versionRepo: versionRepo,
+		index:       index,
+	}
+}
+
+// OnChange регистрирует обработчик изменений индекса. Обработчики
+// вызываются синхронно после обновления индекса.
+func (s *IncidentService) OnChange(fn func(IncidentChange)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.observers = append(s.observers, fn)
+}
+
+// LoadIndex заполняет индекс активными инцидентами из БД. Обработчики
+// получают только зоны, которые отличаются от прежнего содержимого.
+func (s *IncidentService) LoadIndex() error {
+	incidents, err := s.repo.GetActive()
+	if err != nil {
+		return err
+	}
+
+	previous := make(map[int64]domain.Incident)
+	for _, i := range s.index.All() {
+		previous[i.ID] = i
+	}
+
+	s.index.Load(incidents)
+
+	now := time.Now()
+
+	for _, i := range incidents {
+		if !i.ActiveAt(now) {
+			continue
+		}
+		before, ok := previous[i.ID]
+		delete(previous, i.ID)
+		if ok && reflect.DeepEqual(before, i) {
+			continue
+		}
+		after := i
+		if ok {
+			s.notify(IncidentChange{Before: &before, After: &after})
+		} else {
+			s.notify(IncidentChange{After: &after})
+		}
+	}
+	for _, before := range previous {
+		s.notify(IncidentChange{Before: &before})
+	}
+
+	return nil
+}
+
+// RefreshIndex перечитывает инцидент из БД и обновляет индекс.
+func (s *IncidentService) RefreshIndex(id int64) error {
+	incident, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if incident == nil {
+		s.indexRemove(id, false)
+		return nil
+	}
+	s.indexUpsert(*incident, false)
+	return nil
+}
+
+// SyncIndex применяет уведомления об изменениях инцидентов, сделанных
+// другими репликами. После (пере)подключения индекс загружается целиком,
+// так как часть уведомлений могла потеряться.
+func (s *IncidentService) SyncIndex(ctx context.Context, notifications <-chan postgres.Notification) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+
+			if n.Reconnected {
+				if err := s.LoadIndex(); err != nil {
+					log.Println("incident index reload error:", err)
+				}
+				continue
+			}
+
+			var change struct {
+				ID int64 `json:"id"`
+			}
+			if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+				log.Println("incident change payload error:", err)
+				continue
+			}
+
+			if err := s.RefreshIndex(change.ID); err != nil {
+				log.Println("incident index refresh error:", err)
+			}
+		}
+	}
+}
+
+// RunSchedule применяет окна действия инцидентов: раз в interval добавляет
+// в индекс зоны, чьё starts_at наступило, и деактивирует истёкшие, отправляя
+// событие incident.expired. Истечение выполняется в БД атомарно, поэтому
+// при нескольких репликах событие отправляется один раз; начало действия
+// каждая реплика отслеживает сама, а пользователей внутри начавшей
+// действовать зоны уведомляет одна — захватившая его (ClaimStart).
+func (s *IncidentService) RunSchedule(ctx context.Context, interval time.Duration, publisher AlertPublisher) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now().UTC()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			// При ошибке окно не сдвигается: зоны, начавшие действовать в нём,
+			// обработаются на следующем тике
+			if err := s.applySchedule(last, now, publisher); err != nil {
+				log.Println("incident schedule error:", err)
+				continue
+			}
+			last = now
+		}
+	}
+}
+
+// applySchedule возвращает ошибку, если начало действия обработано не для
+// всех зон окна (from, to]; повторная обработка уже добавленных зон ничего
+// не меняет. Истечение от окна не зависит и выполняется в любом случае.
+func (s *IncidentService) applySchedule(from, to time.Time, publisher AlertPublisher) error {
+	err := s.applyStarts(from, to)
+	s.applyExpiry(to, publisher)
+	return err
+}
+
+func (s *IncidentService) applyStarts(from, to time.Time) error {
+	started, err := s.repo.StartedBetween(from, to)
+	if err != nil {
+		return err
+	}
+	for _, i := range started {
+		local, err := s.repo.ClaimStart(i.ID, *i.StartsAt)
+		if err != nil {
+			return fmt.Errorf("claim start of incident %d: %w", i.ID, err)
+		}
+		s.indexUpsert(i, local)
+	}
+	return nil
+}
+
+func (s *IncidentService) applyExpiry(to time.Time, publisher AlertPublisher) {
+	expired, err := s.repo.ExpireDue(to)
+	if err != nil {
+		log.Println("incident expiry error:", err)
+		return
+	}
+	for _, i := range expired {
+		s.indexRemove(i.ID, true)
+	}
+
+	if len(expired) > 0 && publisher != nil {
+		if err := publisher.Publish(domain.EventExpired, "", expired); err != nil {
+			log.Println("webhook enqueue error:", err)
+		}
 	}
 }
 
@@ -30,12 +232,27 @@ func (s *IncidentService) Create(incident *domain.Incident) error {
 	if incident == nil {
 		return errors.New("incident is nil")
 	}
-	return s.repo.Create(incident)
+	applyDefaults(incident)
+	applyBoundingCircle(incident, s.index.Distance())
+	if err := s.repo.Create(incident); err != nil {
+		return err
+	}
+	return s.reindex(incident)
 }
 
-func (s *IncidentService) List(page, limit int) ([]domain.Incident, error) {
-	offset := (page - 1) * limit
-	return s.repo.List(limit, offset)
+// List возвращает страницу инцидентов и общее число подходящих под фильтр.
+func (s *IncidentService) List(filter domain.IncidentFilter, page domain.IncidentPage) ([]domain.Incident, int, error) {
+	incidents, err := s.repo.List(filter, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return incidents, total, nil
 }
 
 func (s *IncidentService) GetByID(id int64) (*domain.Incident, error) {
@@ -46,11 +263,209 @@ func (s *IncidentService) Update(incident *domain.Incident) error {
 	if incident == nil {
 		return errors.New("incident is nil")
 	}
-	return s.repo.Update(incident)
+	applyDefaults(incident)
+	applyBoundingCircle(incident, s.index.Distance())
+	if err := s.repo.Update(incident); err != nil {
+		return err
+	}
+	return s.reindex(incident)
+}
+
+// Deactivate снимает зону; actor — идентификатор автора для истории.
+func (s *IncidentService) Deactivate(id int64, actor string) error {
+	if _, err := s.mustGet(id); err != nil {
+		return err
+	}
+	if err := s.repo.Deactivate(id, actor); err != nil {
+		return err
+	}
+	s.indexRemove(id, true)
+	return nil
+}
+
+// Activate возвращает ранее снятую зону.
+func (s *IncidentService) Activate(id int64, actor string) error {
+	incident, err := s.mustGet(id)
+	if err != nil {
+		return err
+	}
+	if incident.ExpiresAt != nil && !time.Now().Before(*incident.ExpiresAt) {
+		return ErrIncidentExpired
+	}
+
+	if err := s.repo.Activate(id, actor); err != nil {
+		return err
+	}
+	return s.reindex(incident)
+}
+
+// Purge удаляет инцидент безвозвратно (для созданных по ошибке).
+// История версий остаётся с записью об удалении.
+func (s *IncidentService) Purge(id int64, actor string) error {
+	if _, err := s.mustGet(id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, actor); err != nil {
+		return err
+	}
+	s.indexRemove(id, true)
+	return nil
+}
+
+// reindex перечитывает записанный инцидент и кладёт в индекс (и в
+// incident) строку в том виде, в каком её хранит БД: с created_at,
+// updated_at и временами в UTC. Иначе NOTIFY об этом же изменении,
+// вернувшийся на реплику, отличался бы от индекса и рассылался как
+// чужое изменение.
+func (s *IncidentService) reindex(incident *domain.Incident) error {
+	stored, err := s.repo.GetByID(incident.ID)
+	if err != nil {
+		// Запись прошла; индекс догонит NOTIFY
+		log.Println("incident reload error:", err)
+		return nil
+	}
+	if stored == nil {
+		s.indexRemove(incident.ID, true)
+		return ErrIncidentNotFound
+	}
+
+	*incident = *stored
+	s.indexUpsert(*stored, true)
+	return nil
+}
+
+func (s *IncidentService) mustGet(id int64) (*domain.Incident, error) {
+	incident, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if incident == nil {
+		return nil, ErrIncidentNotFound
+	}
+	return incident, nil
+}
+
+// History возвращает все версии инцидента, от первой к последней.
+func (s *IncidentService) History(id int64) ([]domain.IncidentVersion, error) {
+	return s.versionRepo.List(id)
+}
+
+// GetAt возвращает версию, действовавшую на момент at (nil, если инцидент
+// ещё не был создан). Если к этому моменту он был удалён, у версии
+// Op = delete, а снимок — состояние перед удалением.
+func (s *IncidentService) GetAt(id int64, at time.Time) (*domain.IncidentVersion, error) {
+	return s.versionRepo.GetAt(id, at)
 }
 
-func (s *IncidentService) Deactivate(id int64) error {
-	return s.repo.Deactivate(id)
+// Upsert обновляет инцидент, если задан ID, иначе создаёт новый.
+// Возвращает true, если инцидент был создан.
+func (s *IncidentService) Upsert(incident *domain.Incident) (bool, error) {
+	if incident == nil {
+		return false, errors.New("incident is nil")
+	}
+
+	if incident.ID == 0 {
+		return true, s.Create(incident)
+	}
+
+	existing, err := s.repo.GetByID(incident.ID)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		return false, ErrIncidentNotFound
+	}
+
+	incident.CreatedAt = existing.CreatedAt
+	return false, s.Update(incident)
+}
+
+// Export возвращает все инциденты (activeOnly=false) или только активные.
+func (s *IncidentService) Export(activeOnly bool) ([]domain.Incident, error) {
+	if activeOnly {
+		return s.repo.GetActive()
+	}
+
+	page := domain.IncidentPage{Sort: domain.IncidentSortID, Limit: 500}
+
+	var all []domain.Incident
+	for {
+		incidents, err := s.repo.List(domain.IncidentFilter{}, page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, incidents...)
+		if len(incidents) < page.Limit {
+			return all, nil
+		}
+		cursor := domain.CursorOf(incidents[len(incidents)-1])
+		page.After = &cursor
+	}
+}
+
+// indexUpsert обновляет индекс и сообщает об изменении, если оно затронуло
+// активные зоны.
+func (s *IncidentService) indexUpsert(incident domain.Incident, local bool) {
+	before, existed := s.index.Get(incident.ID)
+	s.index.Upsert(incident)
+
+	change := IncidentChange{Local: local}
+	if existed {
+		change.Before = &before
+	}
+	if incident.ActiveAt(time.Now()) {
+		change.After = &incident
+	}
+	if change.Before == nil && change.After == nil {
+		return
+	}
+	if change.Before != nil && change.After != nil && reflect.DeepEqual(before, incident) {
+		return
+	}
+	s.notify(change)
+}
+
+func (s *IncidentService) indexRemove(id int64, local bool) {
+	before, existed := s.index.Get(id)
+	s.index.Remove(id)
+
+	if existed {
+		s.notify(IncidentChange{Before: &before, Local: local})
+	}
+}
+
+func (s *IncidentService) notify(change IncidentChange) {
+	s.mu.Lock()
+	observers := s.observers
+	s.mu.Unlock()
+
+	for _, fn := range observers {
+		fn(change)
+	}
+}
+
+// applyDefaults заполняет уровень и категорию, если они не заданы
+// (например, при импорте GeoJSON без этих свойств).
+func applyDefaults(incident *domain.Incident) {
+	if incident.Severity == "" {
+		incident.Severity = domain.DefaultSeverity
+	}
+	if incident.Category == "" {
+		incident.Category = domain.DefaultCategory
+	}
+}
+
+// Для полигональных и линейных зон центр и радиус вычисляются из
+// геометрии, чтобы круговые запросы (статистика, выборки, индекс)
+// оставались корректными. Окружность линейной зоны включает ширину полосы.
+func applyBoundingCircle(incident *domain.Incident, dist DistanceCalculator) {
+	if incident.Geometry == nil {
+		return
+	}
+	incident.Lat, incident.Lon, incident.RadiusM = BoundingCircle(dist, *incident.Geometry)
+	if incident.Geometry.Type == domain.GeometryLineString {
+		incident.RadiusM += incident.LineBufferM
+	}
 }
 
 // =====================
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+// storedIncidents — IncidentRepository в памяти, который, как Postgres,
+// сам ставит created_at/updated_at и возвращает времена в UTC. Методы,
+// которые тесты не вызывают, остаются у встроенного nil-интерфейса.
+type storedIncidents struct {
+	repository.IncidentRepository
+
+	mu     sync.Mutex
+	nextID int64
+	rows   map[int64]domain.Incident
+}
+
+func (m *storedIncidents) store(i domain.Incident) {
+	utc := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		v := t.UTC()
+		return &v
+	}
+	i.StartsAt, i.ExpiresAt = utc(i.StartsAt), utc(i.ExpiresAt)
+	i.UpdatedAt = time.Now().UTC()
+	m.rows[i.ID] = i
+}
+
+func (m *storedIncidents) Create(i *domain.Incident) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rows == nil {
+		m.rows = make(map[int64]domain.Incident)
+	}
+	m.nextID++
+	i.ID = m.nextID
+	i.CreatedAt = time.Now().UTC()
+	m.store(*i)
+	return nil
+}
+
+func (m *storedIncidents) Update(i *domain.Incident) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.rows[i.ID]; ok {
+		u := *i
+		u.CreatedAt = old.CreatedAt
+		m.store(u)
+	}
+	return nil
+}
+
+func (m *storedIncidents) GetByID(id int64) (*domain.Incident, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.rows[id]
+	if !ok {
+		return nil, nil
+	}
+	return &i, nil
+}
+
+// flakySchedule — storedIncidents, у которого первые failures вызовов
+// StartedBetween завершаются ошибкой.
+type flakySchedule struct {
+	storedIncidents
+	failures int
+}
+
+func (m *flakySchedule) StartedBetween(from, to time.Time) ([]domain.Incident, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures > 0 {
+		m.failures--
+		return nil, errors.New("connection reset")
+	}
+
+	var started []domain.Incident
+	for _, i := range m.rows {
+		if i.Active && i.StartsAt != nil && i.StartsAt.After(from) && !i.StartsAt.After(to) {
+			started = append(started, i)
+		}
+	}
+	return started, nil
+}
+
+func (m *flakySchedule) ClaimStart(id int64, startsAt time.Time) (bool, error) {
+	return true, nil
+}
+
+func (m *flakySchedule) ExpireDue(now time.Time) ([]domain.Incident, error) {
+	return nil, nil
+}
+
+func TestRunScheduleRetriesFailedWindow(t *testing.T) {
+	repo := &flakySchedule{failures: 2}
+	index := NewIncidentIndex(200, Haversine{})
+	s := NewIncidentService(repo, nil, nil, index)
+
+	// Зона начинает действовать сразу после запуска расписания, но первые
+	// два запроса окна падают
+	startsAt := time.Now().Add(20 * time.Millisecond)
+	incident := &domain.Incident{Title: "Fire", Lat: 43.24, Lon: 76.89, RadiusM: 100, Active: true, StartsAt: &startsAt}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunSchedule(ctx, 50*time.Millisecond, nil)
+	}()
+
+	if err := s.Create(incident); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for index.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if _, ok := index.Get(incident.ID); !ok {
+		t.Fatal("incident started in a failed window never reached the index")
+	}
+}
+
+func TestUpdateOwnNotificationIsNotAChange(t *testing.T) {
+	repo := &storedIncidents{}
+	s := NewIncidentService(repo, nil, nil, NewIncidentIndex(200, Haversine{}))
+
+	var changes []IncidentChange
+	s.OnChange(func(c IncidentChange) { changes = append(changes, c) })
+
+	almaty := time.FixedZone("UTC+5", 5*60*60)
+	startsAt := time.Now().In(almaty).Add(-time.Hour)
+
+	incident := &domain.Incident{Title: "Fire", Lat: 43.24, Lon: 76.89, RadiusM: 100, Active: true, StartsAt: &startsAt}
+	if err := s.Create(incident); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	update := &domain.Incident{ID: incident.ID, Title: "Fire", Lat: 43.24, Lon: 76.89, RadiusM: 300, Active: true, StartsAt: &startsAt}
+	if err := s.Update(update); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if update.CreatedAt.IsZero() || update.UpdatedAt.IsZero() {
+		t.Fatalf("Update left timestamps empty: %+v", update)
+	}
+
+	// NOTIFY об этих же изменениях возвращается на реплику
+	if err := s.RefreshIndex(incident.ID); err != nil {
+		t.Fatalf("RefreshIndex: %v", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2 (create, update)", len(changes))
+	}
+	for k, c := range changes {
+		if !c.Local {
+			t.Errorf("change %d is not local", k)
+		}
+	}
+}
@@ -1,59 +1,654 @@
 package service
 
 import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/kassse1/geo-alert-core/internal/domain"
 	"github.com/kassse1/geo-alert-core/internal/repository"
 )
 
 type LocationService struct {
-	incidentRepo repository.IncidentRepository
-	checkRepo    repository.LocationCheckRepository
-	webhook      *WebhookService
+	index     *IncidentIndex
+	spatial   repository.SpatialIncidentRepository
+	bufferM   float64
+	checkRepo repository.LocationCheckRepository
+	webhook   AlertPublisher
+	tracker   *TransitionTracker
+	hub       *AlertHub
+
+	broadcastWindow   time.Duration
+	predictionHorizon time.Duration
+
+	// Очередь рассылок по изменениям инцидентов (см. RunBroadcasts)
+	mu      sync.Mutex
+	pending []IncidentChange
+	wake    chan struct{}
 }
 
+// spatial может быть nil — тогда зоны для проверки точки отбираются по
+// индексу, иначе запросом к БД (PostGIS) с буфером приближения
+// approachBufferM для зон без своего (тем же, что у index). Индекс нужен
+// и с PostGIS: по нему работают ближайшие зоны, маршруты, прогноз, поток
+// событий и запасной поиск при ошибке запроса. tracker может быть nil —
+// тогда вебхук отправляется на каждую проверку внутри зоны, а не только
+// на входе/выходе. broadcastWindow — насколько
+// свежей должна быть последняя проверка пользователя, чтобы он получил
+// уведомление о новой зоне без повторной проверки (см. IncidentChanged).
+// predictionHorizon — на сколько вперёд прогнозируется путь движущегося
+// пользователя (0 — без прогноза).
 func NewLocationService(
-	incidentRepo repository.IncidentRepository,
+	index *IncidentIndex,
+	spatial repository.SpatialIncidentRepository,
+	approachBufferM int,
 	checkRepo repository.LocationCheckRepository,
-	webhook *WebhookService,
+	webhook AlertPublisher,
+	tracker *TransitionTracker,
+	hub *AlertHub,
+	broadcastWindow time.Duration,
+	predictionHorizon time.Duration,
 ) *LocationService {
 	return &LocationService{
-		incidentRepo: incidentRepo,
-		checkRepo:    checkRepo,
-		webhook:      webhook,
+		index:             index,
+		spatial:           spatial,
+		bufferM:           float64(approachBufferM),
+		checkRepo:         checkRepo,
+		webhook:           webhook,
+		tracker:           tracker,
+		hub:               hub,
+		broadcastWindow:   broadcastWindow,
+		predictionHorizon: predictionHorizon,
+		wake:              make(chan struct{}, 1),
 	}
 }
 
-func (s *LocationService) CheckLocation(
-	userID string,
-	lat, lon float64,
-) ([]domain.Incident, error) {
+// PositionReport — позиция пользователя; нулевой Timestamp означает «сейчас».
+// Motion — скорость и курс от клиента; nil — оценить по предыдущей позиции.
+type PositionReport struct {
+	UserID    string
+	Lat       float64
+	Lon       float64
+	Timestamp time.Time
+	Motion    *Motion
+}
 
-	//  Получаем только АКТИВНЫЕ инциденты
-	incidents, err := s.incidentRepo.GetActive()
-	if err != nil {
+// alertEvent — событие для отправки получателям вебхуков.
+type alertEvent struct {
+	eventType string
+	incidents []domain.Incident
+}
+
+func (s *LocationService) CheckLocation(r PositionReport) ([]domain.IncidentMatch, error) {
+	at := reportTime(r, time.Now().UTC())
+
+	//  Скорость и курс не переданы — оцениваем по предыдущей проверке
+	//  (до сохранения текущей)
+	motion := r.Motion
+	if motion == nil {
+		motion = s.recentMotion(r, at)
+	}
+
+	//  Ищем зоны, в которые попадает точка, к которым она приближается
+	//  или которые лежат на пути пользователя (по индексу или, с PostGIS,
+	//  запросом к БД)
+	nearby := s.matchMoving(r.Lat, r.Lon, motion)
+
+	//  Сохраняем факт проверки вместе с результатом (не блокирует ответ)
+	check := newLocationCheck(s.index.Distance(), r.UserID, r.Lat, r.Lon, nearby)
+	if !r.Timestamp.IsZero() {
+		check.CheckedAt = at
+	}
+	if err := s.checkRepo.Save(check); err != nil {
+		log.Println("location check save error:", err)
+	}
+
+	s.publish(r.UserID, s.events(r.UserID, nearby, at))
+
+	//  Клиенты, подключённые к потоку, получают изменения сразу
+	s.hub.ReportPositions([]HubPosition{{
+		UserPosition: domain.UserPosition{UserID: r.UserID, Lat: r.Lat, Lon: r.Lon},
+		Incidents:    nearby,
+	}})
+
+	return nearby, nil
+}
+
+// CheckBatch проверяет пачку позиций: результаты возвращаются в порядке
+// reports, все проверки сохраняются одним запросом, а события каждого
+// пользователя объединяются и отправляются один раз. Без переданных
+// скорости и курса движение оценивается только по предыдущей позиции
+// того же пользователя в пачке.
+func (s *LocationService) CheckBatch(reports []PositionReport) ([][]domain.IncidentMatch, error) {
+	results := make([][]domain.IncidentMatch, len(reports))
+	checks := make([]*domain.LocationCheck, len(reports))
+
+	now := time.Now().UTC()
+
+	// Позиции одного пользователя обрабатываем в хронологическом порядке
+	order := make([]int, len(reports))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return reportTime(reports[order[a]], now).Before(reportTime(reports[order[b]], now))
+	})
+
+	previous := make(map[string]PositionReport)
+
+	for _, idx := range order {
+		r := reports[idx]
+
+		motion := r.Motion
+		if prev, ok := previous[r.UserID]; ok && motion == nil && s.predictionHorizon > 0 {
+			if m, ok := motionBetween(s.index.Distance(), prev.Lat, prev.Lon, reportTime(prev, now), r.Lat, r.Lon, reportTime(r, now)); ok {
+				motion = &m
+			}
+		}
+		if r.UserID != "" {
+			previous[r.UserID] = r
+		}
+
+		results[idx] = s.matchMoving(r.Lat, r.Lon, motion)
+
+		checks[idx] = newLocationCheck(s.index.Distance(), r.UserID, r.Lat, r.Lon, results[idx])
+		if !r.Timestamp.IsZero() {
+			checks[idx].CheckedAt = r.Timestamp.UTC()
+		}
+	}
+
+	if err := s.checkRepo.SaveBatch(checks); err != nil {
 		return nil, err
 	}
 
-	//  Фильтруем по расстоянию
-	nearby := make([]domain.Incident, 0)
-	for _, i := range incidents {
-		distance := DistanceMeters(lat, lon, i.Lat, i.Lon)
-		if distance <= float64(i.RadiusM) {
-			nearby = append(nearby, i)
+	byUser := make(map[string][]alertEvent)
+	var users []string
+
+	for _, idx := range order {
+		r := reports[idx]
+		if _, ok := byUser[r.UserID]; !ok {
+			users = append(users, r.UserID)
 		}
+		byUser[r.UserID] = mergeEvents(byUser[r.UserID], s.events(r.UserID, results[idx], reportTime(r, now)))
+	}
+
+	for _, userID := range users {
+		s.publish(userID, byUser[userID])
+	}
+
+	// В поток уходит последняя по времени позиция каждого пользователя
+	latest := make(map[string]int, len(users))
+	for _, idx := range order {
+		latest[reports[idx].UserID] = idx
+	}
+
+	positions := make([]HubPosition, 0, len(users))
+	for _, userID := range users {
+		r := reports[latest[userID]]
+		positions = append(positions, HubPosition{
+			UserPosition: domain.UserPosition{UserID: r.UserID, Lat: r.Lat, Lon: r.Lon},
+			Incidents:    results[latest[userID]],
+		})
+	}
+	s.hub.ReportPositions(positions)
+
+	return results, nil
+}
+
+// Subscribe подключает клиента к потоку событий пользователя (см. AlertHub).
+func (s *LocationService) Subscribe(userID string, lastEventID uint64) (<-chan StreamEvent, []StreamEvent, func()) {
+	return s.hub.Subscribe(userID, lastEventID)
+}
+
+// IncidentChanged уведомляет пользователей, которые уже находятся внутри
+// созданной или расширенной зоны (или в её буфере приближения): по
+// последней проверке каждого из них за broadcastWindow. Обрабатываются
+// только локальные изменения — реплика, изменившая инцидент, рассылает
+// уведомления одна. Рассылка ставится в очередь и выполняется в
+// RunBroadcasts, а не в запросе, изменившем инцидент: для большой зоны
+// это поиск по проверкам и уведомление каждого пользователя.
+func (s *LocationService) IncidentChanged(change IncidentChange) {
+	if !change.Local || change.After == nil || s.broadcastWindow <= 0 {
+		return
 	}
 
-	//  Сохраняем факт проверки (не блокирует ответ)
-	_ = s.checkRepo.Save(&domain.LocationCheck{
-		UserID: userID,
-		Lat:    lat,
-		Lon:    lon,
+	s.mu.Lock()
+	s.pending = append(s.pending, change)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunBroadcasts выполняет рассылки из очереди IncidentChanged по одной, в
+// порядке изменений, до отмены ctx.
+func (s *LocationService) RunBroadcasts(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			dropped := len(s.pending)
+			s.mu.Unlock()
+			if dropped > 0 {
+				log.Printf("incident broadcast: %d pending changes dropped on shutdown", dropped)
+			}
+			return
+		case <-s.wake:
+		}
+
+		for ctx.Err() == nil {
+			change, ok := s.nextBroadcast()
+			if !ok {
+				break
+			}
+			s.broadcast(change)
+		}
+	}
+}
+
+func (s *LocationService) nextBroadcast() (IncidentChange, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return IncidentChange{}, false
+	}
+	change := s.pending[0]
+	s.pending[0] = IncidentChange{}
+	s.pending = s.pending[1:]
+	return change, true
+}
+
+func (s *LocationService) broadcast(change IncidentChange) {
+	incident := *change.After
+	buffer := s.index.ApproachBuffer(incident)
+	dist := s.index.Distance()
+
+	minLat, minLon, maxLat, maxLon := circleBounds(incident.Lat, incident.Lon, float64(incident.RadiusM)+buffer)
+	checks, err := s.checkRepo.LatestInArea(
+		int(math.Ceil(s.broadcastWindow.Minutes())),
+		domain.BoundingBox{MinLat: minLat, MinLon: minLon, MaxLat: maxLat, MaxLon: maxLon},
+	)
+	if err != nil {
+		log.Println("incident broadcast lookup error:", err)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	for _, c := range checks {
+		m, ok := matchIncident(dist, incident, c.Lat, c.Lon, buffer)
+		if !ok {
+			continue
+		}
+		// Те, кто был в том же или более опасном положении и до
+		// изменения, уже получили уведомление
+		if change.Before != nil {
+			prev, ok := matchIncident(dist, *change.Before, c.Lat, c.Lon, s.index.ApproachBuffer(*change.Before))
+			if ok && (prev.Inside() || !m.Inside()) {
+				continue
+			}
+		}
+
+		if s.tracker == nil {
+			eventType := domain.EventAlert
+			if !m.Inside() {
+				eventType = domain.EventApproaching
+			}
+			s.publish(c.UserID, []alertEvent{{eventType, []domain.Incident{incident}}})
+			continue
+		}
+		s.publish(c.UserID, s.events(c.UserID, s.match(c.Lat, c.Lon), now))
+	}
+}
+
+// Наибольший радиус поиска ближайших зон — половина окружности Земли.
+const maxNearbyRadiusM = math.Pi * earthRadiusMeters
+
+// Nearest возвращает активные зоны в пределах radiusM от точки (0 — без
+// ограничения), отсортированные по расстоянию до границы; limit > 0
+// ограничивает число результатов. Без радиуса область поиска расширяется,
+// пока не найдётся limit зон.
+func (s *LocationService) Nearest(lat, lon, radiusM float64, limit int) []domain.NearbyIncident {
+	if radiusM > 0 {
+		return s.nearestWithin(lat, lon, radiusM, limit)
+	}
+
+	for r := 1000.0; ; r *= 4 {
+		r = math.Min(r, maxNearbyRadiusM)
+		found := s.nearestWithin(lat, lon, r, limit)
+		if (limit > 0 && len(found) >= limit) || r >= maxNearbyRadiusM {
+			return found
+		}
+	}
+}
+
+func (s *LocationService) nearestWithin(lat, lon, radiusM float64, limit int) []domain.NearbyIncident {
+	now := time.Now()
+	dist := s.index.Distance()
+
+	nearby := make([]domain.NearbyIncident, 0)
+	for _, i := range s.index.Near(lat, lon, radiusM) {
+		if !i.ActiveAt(now) {
+			continue
+		}
+
+		nLat, nLon, d := NearestPoint(dist, i, lat, lon)
+		if d > radiusM {
+			continue
+		}
+
+		n := domain.NearbyIncident{Incident: i, DistanceM: d, Inside: d == 0}
+		if n.Inside {
+			n.BearingDeg = BearingDegrees(lat, lon, i.Lat, i.Lon)
+		} else {
+			n.BearingDeg = BearingDegrees(lat, lon, nLat, nLon)
+		}
+		nearby = append(nearby, n)
+	}
+
+	sort.Slice(nearby, func(a, b int) bool {
+		if nearby[a].DistanceM != nearby[b].DistanceM {
+			return nearby[a].DistanceM < nearby[b].DistanceM
+		}
+		return nearby[a].Incident.ID < nearby[b].Incident.ID
 	})
 
-	//  Асинхронно отправляем webhook, если есть опасности
-	if len(nearby) > 0 && s.webhook != nil {
-		go s.webhook.Send(userID, nearby)
+	if limit > 0 && len(nearby) > limit {
+		nearby = nearby[:limit]
 	}
+	return nearby
+}
 
-	return nearby, nil
+// History возвращает сохранённые проверки с их результатами.
+func (s *LocationService) History(filter domain.LocationCheckFilter) ([]domain.LocationCheck, error) {
+	return s.checkRepo.List(filter)
+}
+
+// match возвращает активные зоны, содержащие точку или близкие к ней.
+// С PostGIS кандидатов отбирает БД; при ошибке запроса — индекс.
+func (s *LocationService) match(lat, lon float64) []domain.IncidentMatch {
+	if s.spatial == nil {
+		return matchIncidents(s.index, lat, lon)
+	}
+
+	candidates, err := s.spatial.ActiveNear(lat, lon, s.bufferM)
+	if err != nil {
+		log.Println("spatial incident lookup error:", err)
+		return matchIncidents(s.index, lat, lon)
+	}
+	return matchCandidates(s.index, candidates, lat, lon)
+}
+
+// matchMoving дополняет match зонами на прогнозируемом пути пользователя.
+func (s *LocationService) matchMoving(lat, lon float64, motion *Motion) []domain.IncidentMatch {
+	matched := s.match(lat, lon)
+	if motion == nil || s.predictionHorizon <= 0 {
+		return matched
+	}
+	return withPredictions(matched, predictIncidents(s.index, lat, lon, *motion, s.predictionHorizon))
+}
+
+// recentMotion оценивает скорость и курс по предыдущей проверке пользователя.
+func (s *LocationService) recentMotion(r PositionReport, at time.Time) *Motion {
+	if r.UserID == "" || s.predictionHorizon <= 0 {
+		return nil
+	}
+
+	prev, err := s.checkRepo.LatestByUser(r.UserID, at)
+	if err != nil {
+		log.Println("previous location check lookup error:", err)
+		return nil
+	}
+	if prev == nil {
+		return nil
+	}
+
+	m, ok := motionBetween(s.index.Distance(), prev.Lat, prev.Lon, prev.CheckedAt, r.Lat, r.Lon, at)
+	if !ok {
+		return nil
+	}
+	return &m
+}
+
+// matchIncidents возвращает зоны, содержащие точку, а за ними — зоны, в
+// буфер приближения которых она попадает. Внутри каждой группы сначала
+// самые опасные, при равном уровне — с ближайшим центром (для зон, к
+// которым точка приближается, — с ближайшей границей).
+func matchIncidents(index *IncidentIndex, lat, lon float64) []domain.IncidentMatch {
+	return matchCandidates(index, index.Candidates(lat, lon), lat, lon)
+}
+
+// matchCandidates — matchIncidents по заранее отобранным зонам; буфер
+// приближения и способ расчёта расстояний берутся из index.
+func matchCandidates(index *IncidentIndex, candidates []domain.Incident, lat, lon float64) []domain.IncidentMatch {
+	now := time.Now()
+	dist := index.Distance()
+
+	// Истёкшие зоны остаются в индексе до следующего запуска RunSchedule,
+	// поэтому окно действия проверяется и здесь.
+	matched := make([]domain.IncidentMatch, 0)
+	for _, i := range candidates {
+		if !i.ActiveAt(now) {
+			continue
+		}
+		if m, ok := matchIncident(dist, i, lat, lon, index.ApproachBuffer(i)); ok {
+			matched = append(matched, m)
+		}
+	}
+
+	sort.SliceStable(matched, func(a, b int) bool {
+		ma, mb := matched[a], matched[b]
+		if ma.Inside() != mb.Inside() {
+			return ma.Inside()
+		}
+		ra, rb := domain.SeverityRank(ma.Severity), domain.SeverityRank(mb.Severity)
+		if ra != rb {
+			return ra > rb
+		}
+		da, db := ma.DistanceM, mb.DistanceM
+		if ma.Inside() {
+			da = dist.Distance(lat, lon, ma.Lat, ma.Lon)
+			db = dist.Distance(lat, lon, mb.Lat, mb.Lon)
+		}
+		if da != db {
+			return da < db
+		}
+		return ma.ID < mb.ID
+	})
+
+	return matched
+}
+
+// matchIncident определяет положение точки относительно зоны: внутри,
+// в буфере приближения шириной bufferM или вне их (false).
+func matchIncident(dist DistanceCalculator, i domain.Incident, lat, lon, bufferM float64) (domain.IncidentMatch, bool) {
+	if IncidentContains(dist, i, lat, lon) {
+		return domain.IncidentMatch{Incident: i, Status: domain.MatchInside}, true
+	}
+	if bufferM <= 0 {
+		return domain.IncidentMatch{}, false
+	}
+
+	_, _, d := NearestPoint(dist, i, lat, lon)
+	if d > bufferM {
+		return domain.IncidentMatch{}, false
+	}
+	return domain.IncidentMatch{Incident: i, Status: domain.MatchApproaching, DistanceM: d}, true
+}
+
+// incidentsWithStatus выбирает из результата проверки зоны с положением status.
+func incidentsWithStatus(matched []domain.IncidentMatch, status string) []domain.Incident {
+	incidents := make([]domain.Incident, 0, len(matched))
+	for _, m := range matched {
+		if m.Status == status {
+			incidents = append(incidents, m.Incident)
+		}
+	}
+	return incidents
+}
+
+// newLocationCheck фиксирует, что было сообщено пользователю: каждую зону
+// с положением относительно неё и расстоянием до границы (для
+// прогнозируемых — путём до входа), а также (как и раньше) зоны, внутри
+// которых точка, и расстояние до центра ближайшей из них.
+func newLocationCheck(dist DistanceCalculator, userID string, lat, lon float64, nearby []domain.IncidentMatch) *domain.LocationCheck {
+	inside := incidentsWithStatus(nearby, domain.MatchInside)
+
+	check := &domain.LocationCheck{
+		UserID:      userID,
+		Lat:         lat,
+		Lon:         lon,
+		IncidentIDs: make([]int64, 0, len(inside)),
+		HasDanger:   len(inside) > 0,
+		Matches:     make([]domain.LocationCheckMatch, 0, len(nearby)),
+	}
+
+	nearest := math.Inf(1)
+	for _, i := range inside {
+		check.IncidentIDs = append(check.IncidentIDs, i.ID)
+		nearest = math.Min(nearest, dist.Distance(lat, lon, i.Lat, i.Lon))
+	}
+	if check.HasDanger {
+		check.DistanceM = int(math.Round(nearest))
+	}
+
+	for _, m := range nearby {
+		check.Matches = append(check.Matches, domain.LocationCheckMatch{
+			IncidentID: m.ID,
+			Status:     m.Status,
+			DistanceM:  int(math.Round(m.DistanceM)),
+		})
+	}
+
+	return check
+}
+
+// events вычисляет события по результату проверки: при отслеживании
+// переходов — вход, выход, длительное нахождение и приближение, иначе —
+// alert на каждую проверку внутри зоны и approaching на каждую проверку
+// в буфере приближения. Прогнозируемые зоны событий не порождают.
+func (s *LocationService) events(userID string, nearby []domain.IncidentMatch, at time.Time) []alertEvent {
+	if s.tracker == nil {
+		var events []alertEvent
+		if inside := incidentsWithStatus(nearby, domain.MatchInside); len(inside) > 0 {
+			events = append(events, alertEvent{domain.EventAlert, inside})
+		}
+		if approaching := incidentsWithStatus(nearby, domain.MatchApproaching); len(approaching) > 0 {
+			events = append(events, alertEvent{domain.EventApproaching, approaching})
+		}
+		return events
+	}
+
+	if userID == "" {
+		return nil
+	}
+
+	var inside, approaching []int64
+	byID := make(map[int64]domain.Incident, len(nearby))
+	for _, m := range nearby {
+		switch m.Status {
+		case domain.MatchInside:
+			inside = append(inside, m.ID)
+		case domain.MatchApproaching:
+			approaching = append(approaching, m.ID)
+		}
+		byID[m.ID] = m.Incident
+	}
+
+	transitions, err := s.tracker.Track(userID, inside, approaching, at)
+	if err != nil {
+		log.Println("zone transition tracking error:", err)
+		return nil
+	}
+
+	var events []alertEvent
+	for _, e := range []struct {
+		eventType string
+		ids       []int64
+	}{
+		{domain.EventEntered, transitions.Entered},
+		{domain.EventDwelled, transitions.Dwelled},
+		{domain.EventExited, transitions.Exited},
+		{domain.EventApproaching, transitions.Approached},
+	} {
+		if len(e.ids) > 0 {
+			events = append(events, alertEvent{e.eventType, s.incidentsByID(e.ids, byID)})
+		}
+	}
+
+	return events
+}
+
+// publish ставит события в outbox вебхуков.
+func (s *LocationService) publish(userID string, events []alertEvent) {
+	if s.webhook == nil {
+		return
+	}
+	for _, e := range events {
+		if err := s.webhook.Publish(e.eventType, userID, e.incidents); err != nil {
+			log.Println("webhook enqueue error:", err)
+		}
+	}
+}
+
+// incidentsByID собирает инциденты для события. Зона, из которой вышел
+// пользователь, может быть уже деактивирована — тогда передаётся только ID.
+func (s *LocationService) incidentsByID(ids []int64, known map[int64]domain.Incident) []domain.Incident {
+	incidents := make([]domain.Incident, 0, len(ids))
+	for _, id := range ids {
+		if i, ok := known[id]; ok {
+			incidents = append(incidents, i)
+		} else if i, ok := s.index.Get(id); ok {
+			incidents = append(incidents, i)
+		} else {
+			incidents = append(incidents, domain.Incident{ID: id})
+		}
+	}
+	return incidents
+}
+
+// mergeEvents объединяет события одного типа, убирая повторы инцидентов.
+func mergeEvents(events []alertEvent, more []alertEvent) []alertEvent {
+	for _, m := range more {
+		pos := -1
+		for idx, e := range events {
+			if e.eventType == m.eventType {
+				pos = idx
+				break
+			}
+		}
+		if pos < 0 {
+			events = append(events, alertEvent{eventType: m.eventType})
+			pos = len(events) - 1
+		}
+
+		for _, i := range m.incidents {
+			if !containsIncident(events[pos].incidents, i.ID) {
+				events[pos].incidents = append(events[pos].incidents, i)
+			}
+		}
+	}
+	return events
+}
+
+func containsIncident(incidents []domain.Incident, id int64) bool {
+	for _, i := range incidents {
+		if i.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func reportTime(r PositionReport, now time.Time) time.Time {
+	if r.Timestamp.IsZero() {
+		return now
+	}
+	return r.Timestamp.UTC()
 }
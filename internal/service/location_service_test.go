@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+// nearSpatial — SpatialIncidentRepository без БД: ActiveNear отдаёт
+// заданные зоны (или ошибку) и запоминает буфер из запроса.
+type nearSpatial struct {
+	repository.SpatialIncidentRepository
+
+	incidents []domain.Incident
+	err       error
+	bufferM   float64
+}
+
+func (r *nearSpatial) ActiveNear(lat, lon, defaultBufferM float64) ([]domain.Incident, error) {
+	r.bufferM = defaultBufferM
+	return r.incidents, r.err
+}
+
+// spatialZones — пожар вокруг точки проверки, наводнение в ~134 м от неё и
+// зона в ~2 км, которую БД может вернуть с запасом.
+func spatialZones() []domain.Incident {
+	incidents := []domain.Incident{
+		{Title: "Fire", Lat: 43.2400, Lon: 76.8900, RadiusM: 100},
+		{Title: "Flood", Lat: 43.2430, Lon: 76.8900, RadiusM: 200},
+		{Title: "Far", Lat: 43.2600, Lon: 76.8900, RadiusM: 100},
+	}
+	for k := range incidents {
+		incidents[k].ID = int64(k + 1)
+		incidents[k].Active = true
+		applyBoundingCircle(&incidents[k], Haversine{})
+	}
+	return incidents
+}
+
+func TestNewLocationCheckKeepsEveryMatch(t *testing.T) {
+	index := NewIncidentIndex(200, Haversine{})
+	index.Load(spatialZones())
+
+	nearby := matchIncidents(index, 43.2400, 76.8900)
+	nearby = append(nearby, domain.IncidentMatch{Incident: domain.Incident{ID: 3}, Status: domain.MatchPredicted, DistanceM: 900})
+
+	check := newLocationCheck(Haversine{}, "truck-1", 43.2400, 76.8900, nearby)
+
+	if !check.HasDanger || len(check.IncidentIDs) != 1 || check.IncidentIDs[0] != 1 || check.DistanceM != 0 {
+		t.Fatalf("inside summary = %v/%v/%d, want incident 1 at 0 m", check.HasDanger, check.IncidentIDs, check.DistanceM)
+	}
+
+	want := map[int64]domain.LocationCheckMatch{
+		1: {IncidentID: 1, Status: domain.MatchInside, DistanceM: 0},
+		2: {IncidentID: 2, Status: domain.MatchApproaching, DistanceM: 134},
+		3: {IncidentID: 3, Status: domain.MatchPredicted, DistanceM: 900},
+	}
+	if len(check.Matches) != len(want) {
+		t.Fatalf("matches = %+v, want %+v", check.Matches, want)
+	}
+	for _, m := range check.Matches {
+		w := want[m.IncidentID]
+		if m.Status != w.Status || m.DistanceM < w.DistanceM-2 || m.DistanceM > w.DistanceM+2 {
+			t.Errorf("match %+v, want %+v", m, w)
+		}
+	}
+}
+
+func TestMatchUsesSpatialCandidates(t *testing.T) {
+	// Индекс пуст: зоны могут прийти только из запроса к БД
+	spatial := &nearSpatial{incidents: spatialZones()}
+	s := NewLocationService(NewIncidentIndex(250, Haversine{}), spatial, 250, nil, nil, nil, nil, 0, 0)
+
+	matched := s.match(43.2400, 76.8900)
+
+	if spatial.bufferM != 250 {
+		t.Errorf("ActiveNear buffer = %v, want 250", spatial.bufferM)
+	}
+	if len(matched) != 2 || matched[0].ID != 1 || !matched[0].Inside() ||
+		matched[1].ID != 2 || matched[1].Status != domain.MatchApproaching {
+		t.Fatalf("matched = %+v, want fire inside and flood approaching", matched)
+	}
+}
+
+func TestMatchFallsBackToIndexOnSpatialError(t *testing.T) {
+	index := NewIncidentIndex(250, Haversine{})
+	index.Load(spatialZones()[:1])
+
+	spatial := &nearSpatial{err: errors.New("connection refused")}
+	s := NewLocationService(index, spatial, 250, nil, nil, nil, nil, 0, 0)
+
+	matched := s.match(43.2400, 76.8900)
+	if len(matched) != 1 || matched[0].ID != 1 || !matched[0].Inside() {
+		t.Fatalf("matched = %+v, want fire from the index", matched)
+	}
+}
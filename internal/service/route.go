@@ -0,0 +1,393 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// ErrRouteTooComplex — маршрут пересекает слишком много зон (или зон со
+// слишком большим числом вершин) для одного запроса.
+var ErrRouteTooComplex = errors.New("route is too complex")
+
+const (
+	// Наибольший объём работы на один маршрут: число операций над рёбрами
+	// и вершинами зон (подбор кандидатов, поиск пересечений, проверки
+	// попадания), чтобы публичный запрос не занимал сервис надолго.
+	routeMaxWork = 2000000
+	// На сколько частей делится ребро полигона при поиске пересечения.
+	routeEdgeSteps = 16
+)
+
+// RouteCrossings возвращает активные зоны, которые пересекает маршрут, с
+// точками входа и выхода (в порядке первого входа), и длину маршрута.
+func (s *LocationService) RouteCrossings(route domain.LineString) ([]domain.RouteCrossing, float64, error) {
+	return routeCrossings(s.index, route, time.Now())
+}
+
+// routeSpan — участок отрезка маршрута внутри зоны, в метрах от начала
+// отрезка (на сфере).
+type routeSpan struct {
+	fromM, toM float64
+}
+
+// routeArc — отрезок маршрута как дуга большого круга: P(τ) = a·cos τ + t·sin τ,
+// τ — угловое расстояние от начала, не больше maxAngle.
+type routeArc struct {
+	lat, lon, bearing float64
+	lengthM           float64
+	a, t, normal      vec3
+	maxAngle          float64
+}
+
+func newRouteArc(a, b domain.Point) routeArc {
+	return newHeadingArc(
+		a.Lat(), a.Lon(),
+		BearingDegrees(a.Lat(), a.Lon(), b.Lat(), b.Lon()),
+		DistanceMeters(a.Lat(), a.Lon(), b.Lat(), b.Lon()),
+	)
+}
+
+// newHeadingArc — дуга длиной lengthM от точки lat/lon по азимуту bearing.
+func newHeadingArc(lat, lon, bearing, lengthM float64) routeArc {
+	arc := routeArc{lat: lat, lon: lon, bearing: bearing, lengthM: lengthM}
+	arc.a = unitVector(arc.lat, arc.lon)
+	arc.t = tangentVector(arc.lat, arc.lon, arc.bearing)
+	arc.normal = arc.a.cross(arc.t)
+	arc.maxAngle = arc.lengthM / earthRadiusMeters
+	return arc
+}
+
+func (arc routeArc) point(offsetM float64) (float64, float64) {
+	return Destination(arc.lat, arc.lon, arc.bearing, offsetM)
+}
+
+// solve возвращает смещения (в метрах), где α·cos τ + β·sin τ = c, где
+// α = v·a, β = v·t.
+func (arc routeArc) solve(v vec3, c float64) []float64 {
+	alpha, beta := v.dot(arc.a), v.dot(arc.t)
+	k := math.Hypot(alpha, beta)
+	if k == 0 || math.Abs(c) > k {
+		return nil
+	}
+
+	phi := math.Atan2(beta, alpha)
+	delta := math.Acos(c / k)
+
+	var offsets []float64
+	for _, tau := range []float64{phi - delta, phi + delta} {
+		tau = math.Mod(tau+4*math.Pi, 2*math.Pi)
+		if tau <= arc.maxAngle {
+			offsets = append(offsets, tau*earthRadiusMeters)
+		}
+	}
+	return offsets
+}
+
+// circleCrossings — где дуга пересекает окружность радиусом radiusM.
+func (arc routeArc) circleCrossings(lat, lon, radiusM float64) []float64 {
+	return arc.solve(unitVector(lat, lon), math.Cos(radiusM/earthRadiusMeters))
+}
+
+// bandCrossings — где дуга пересекает линии на поперечном расстоянии
+// ±widthM от большого круга через a и b (стороны полосы линейной зоны).
+func (arc routeArc) bandCrossings(a, b domain.Point, widthM float64) []float64 {
+	n, ok := unitVector(a.Lat(), a.Lon()).cross(unitVector(b.Lat(), b.Lon())).normalize()
+	if !ok {
+		return nil
+	}
+	side := math.Sin(widthM / earthRadiusMeters)
+	return append(arc.solve(n, side), arc.solve(n, -side)...)
+}
+
+// edgeCrossings — где дуга пересекает ребро полигона. Полигоны проверяются
+// на плоскости lon/lat (ringContains), поэтому ребро — отрезок в этих
+// координатах; точки его пересечения с большим кругом дуги ищутся делением
+// ребра и уточнением пополам.
+func (arc routeArc) edgeCrossings(a, b domain.Point) []float64 {
+	at := func(s float64) vec3 {
+		return unitVector(a.Lat()+(b.Lat()-a.Lat())*s, a.Lon()+(b.Lon()-a.Lon())*s)
+	}
+	side := func(s float64) float64 { return arc.normal.dot(at(s)) }
+
+	var offsets []float64
+	s0, f0 := 0.0, side(0)
+	for k := 1; k <= routeEdgeSteps; k++ {
+		s1 := float64(k) / routeEdgeSteps
+		f1 := side(s1)
+
+		if f0 == 0 || f0*f1 < 0 {
+			lo, hi, flo := s0, s1, f0
+			for range 60 {
+				mid := (lo + hi) / 2
+				fm := side(mid)
+				if fm == 0 || (fm < 0) != (flo < 0) {
+					hi = mid
+				} else {
+					lo, flo = mid, fm
+				}
+			}
+			p := at((lo + hi) / 2)
+			tau := math.Atan2(p.dot(arc.t), p.dot(arc.a))
+			if tau < 0 {
+				tau += 2 * math.Pi
+			}
+			if tau <= arc.maxAngle {
+				offsets = append(offsets, tau*earthRadiusMeters)
+			}
+		}
+		s0, f0 = s1, f1
+	}
+	return offsets
+}
+
+// routeBudget ограничивает работу на маршрут (routeMaxWork).
+type routeBudget struct {
+	left int
+}
+
+func (b *routeBudget) spend(n int) error {
+	b.left -= n
+	if b.left < 0 {
+		return ErrRouteTooComplex
+	}
+	return nil
+}
+
+// incidentSize — число вершин зоны: столько стоит одна проверка попадания
+// или поиск пересечений с ней.
+func incidentSize(i domain.Incident) int {
+	if i.Geometry == nil {
+		return 1
+	}
+	n := len(i.Geometry.Line)
+	for _, p := range i.Geometry.Polygons {
+		for _, r := range p {
+			n += len(r)
+		}
+	}
+	return max(n, 1)
+}
+
+// incidentSpans возвращает участки отрезка внутри зоны. Смещения, где
+// дуга пересекает границу зоны на сфере, делят отрезок на части с
+// одинаковым положением относительно зоны; положение каждой части
+// проверяется в её середине, а границы между частями уточняются
+// делением пополам тем же способом расчёта расстояний, что и проверка
+// координат, — поэтому зона не теряется, как бы мало ни было
+// пересечение.
+func incidentSpans(dist DistanceCalculator, i domain.Incident, arc routeArc, budget *routeBudget) ([]routeSpan, error) {
+	size := incidentSize(i)
+	if err := budget.spend(size); err != nil {
+		return nil, err
+	}
+
+	var cuts []float64
+	switch {
+	case i.Geometry == nil:
+		cuts = arc.circleCrossings(i.Lat, i.Lon, float64(i.RadiusM))
+	case i.Geometry.Type == domain.GeometryLineString:
+		line, width := i.Geometry.Line, float64(i.LineBufferM)
+		for k, p := range line {
+			cuts = append(cuts, arc.circleCrossings(p.Lat(), p.Lon(), width)...)
+			if k > 0 {
+				cuts = append(cuts, arc.bandCrossings(line[k-1], p, width)...)
+			}
+		}
+	default:
+		for _, p := range i.Geometry.Polygons {
+			for _, r := range p {
+				for k := 1; k < len(r); k++ {
+					cuts = append(cuts, arc.edgeCrossings(r[k-1], r[k])...)
+				}
+			}
+		}
+	}
+
+	cuts = append(cuts, 0, arc.lengthM)
+	sort.Float64s(cuts)
+
+	type part struct {
+		fromM, midM, toM float64
+		inside           bool
+	}
+	var parts []part
+	for k := 1; k < len(cuts); k++ {
+		from, to := cuts[k-1], math.Min(cuts[k], arc.lengthM)
+		if to-from <= 1e-6 {
+			continue
+		}
+		if err := budget.spend(size); err != nil {
+			return nil, err
+		}
+		mid := (from + to) / 2
+		lat, lon := arc.point(mid)
+		parts = append(parts, part{fromM: from, midM: mid, toM: to, inside: IncidentContains(dist, i, lat, lon)})
+	}
+
+	var spans []routeSpan
+	for k := 0; k < len(parts); k++ {
+		if !parts[k].inside {
+			continue
+		}
+
+		span := routeSpan{fromM: parts[k].fromM, toM: arc.lengthM}
+		if k > 0 {
+			span.fromM = boundaryOffset(dist, i, arc.lat, arc.lon, arc.bearing, parts[k].midM, parts[k-1].midM)
+		}
+		for k+1 < len(parts) && parts[k+1].inside {
+			k++
+		}
+		if k+1 < len(parts) {
+			span.toM = boundaryOffset(dist, i, arc.lat, arc.lon, arc.bearing, parts[k].midM, parts[k+1].midM)
+		}
+		spans = append(spans, span)
+	}
+
+	return spans, nil
+}
+
+// routeCrossings проходит маршрут по дугам большого круга между
+// вершинами и для каждой зоны, описанная окружность которой задевает
+// дугу, находит участки внутри зоны (см. incidentSpans).
+func routeCrossings(index *IncidentIndex, route domain.LineString, now time.Time) ([]domain.RouteCrossing, float64, error) {
+	dist := index.Distance()
+	budget := &routeBudget{left: routeMaxWork}
+
+	var (
+		crossings []domain.RouteCrossing
+		pos       = make(map[int64]int)
+		open      = make(map[int64]bool)
+		traveled  float64
+	)
+
+	section := func(i domain.Incident) *domain.RouteCrossing {
+		p, ok := pos[i.ID]
+		if !ok {
+			p = len(crossings)
+			pos[i.ID] = p
+			crossings = append(crossings, domain.RouteCrossing{Incident: i})
+		}
+		return &crossings[p]
+	}
+
+	for k := 1; k < len(route); k++ {
+		arc := newRouteArc(route[k-1], route[k])
+		if arc.lengthM == 0 {
+			continue
+		}
+
+		// Точки строятся на сфере; пройденный путь пересчитывается в
+		// расстояние, измеренное dist
+		lengthM := dist.Distance(route[k-1].Lat(), route[k-1].Lon(), route[k].Lat(), route[k].Lon())
+		scale := lengthM / arc.lengthM
+
+		at := func(offsetM float64) domain.RoutePoint {
+			lat, lon := arc.point(offsetM)
+			return domain.RoutePoint{Lat: lat, Lon: lon, DistanceM: traveled + offsetM*scale}
+		}
+
+		midLat, midLon := arc.point(arc.lengthM / 2)
+		candidates := index.Near(midLat, midLon, arc.lengthM/2)
+		if err := budget.spend(len(candidates)); err != nil {
+			return nil, 0, err
+		}
+
+		next := make(map[int64]bool, len(open))
+
+		for _, i := range candidates {
+			if !i.ActiveAt(now) {
+				continue
+			}
+			// Описанная окружность зоны не задевает дугу
+			_, _, d := nearestOnSegment(Haversine{}, i.Lat, i.Lon, route[k-1], route[k])
+			if d > float64(i.RadiusM)*1.01+1 {
+				continue
+			}
+
+			spans, err := incidentSpans(dist, i, arc, budget)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			// Маршрут был в зоне на вершине: участок продолжается, только
+			// если отрезок начинается внутри неё
+			continues := open[i.ID] && len(spans) > 0 && spans[0].fromM == 0
+			if open[i.ID] && !continues {
+				c := &crossings[pos[i.ID]]
+				c.Sections[len(c.Sections)-1].Exit = at(0)
+			}
+			delete(open, i.ID)
+
+			for k, span := range spans {
+				c := section(i)
+				if k > 0 || !continues {
+					c.Sections = append(c.Sections, domain.RouteSection{Entry: at(span.fromM)})
+				}
+				if span.toM < arc.lengthM {
+					c.Sections[len(c.Sections)-1].Exit = at(span.toM)
+				} else {
+					next[i.ID] = true
+				}
+			}
+		}
+
+		// Зоны, из которых маршрут вышел ровно на вершине
+		for id := range open {
+			c := &crossings[pos[id]]
+			c.Sections[len(c.Sections)-1].Exit = at(0)
+		}
+
+		open = next
+		traveled += lengthM
+	}
+
+	end := route[len(route)-1]
+
+	// Маршрут нулевой длины — одна точка
+	if traveled == 0 {
+		for _, i := range index.Candidates(end.Lat(), end.Lon()) {
+			if i.ActiveAt(now) && IncidentContains(dist, i, end.Lat(), end.Lon()) {
+				p := domain.RoutePoint{Lat: end.Lat(), Lon: end.Lon()}
+				section(i).Sections = []domain.RouteSection{{Entry: p, Exit: p}}
+			}
+		}
+	}
+
+	// Маршрут заканчивается внутри зоны
+	for id := range open {
+		c := &crossings[pos[id]]
+		c.Sections[len(c.Sections)-1].Exit = domain.RoutePoint{Lat: end.Lat(), Lon: end.Lon(), DistanceM: traveled}
+	}
+
+	sort.Slice(crossings, func(a, b int) bool {
+		da, db := crossings[a].Sections[0].Entry.DistanceM, crossings[b].Sections[0].Entry.DistanceM
+		if da != db {
+			return da < db
+		}
+		return crossings[a].Incident.ID < crossings[b].Incident.ID
+	})
+
+	return crossings, traveled, nil
+}
+
+// boundaryOffset уточняет делением пополам, где на отрезке (по азимуту
+// bearingDeg от точки lat/lon) между fromM и toM проходит граница зоны.
+func boundaryOffset(dist DistanceCalculator, i domain.Incident, lat, lon, bearingDeg, fromM, toM float64) float64 {
+	pLat, pLon := Destination(lat, lon, bearingDeg, fromM)
+	fromInside := IncidentContains(dist, i, pLat, pLon)
+
+	for math.Abs(toM-fromM) > trajectoryPrecisionM {
+		mid := (fromM + toM) / 2
+		mLat, mLon := Destination(lat, lon, bearingDeg, mid)
+		if IncidentContains(dist, i, mLat, mLon) == fromInside {
+			fromM = mid
+		} else {
+			toM = mid
+		}
+	}
+	return (fromM + toM) / 2
+}
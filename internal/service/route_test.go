@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+func routeIndex(incidents ...domain.Incident) *IncidentIndex {
+	index := NewIncidentIndex(0, Haversine{})
+	for k := range incidents {
+		incidents[k].ID = int64(k + 1)
+		incidents[k].Active = true
+		applyBoundingCircle(&incidents[k], Haversine{})
+	}
+	index.Load(incidents)
+	return index
+}
+
+func square(minLon, minLat, maxLon, maxLat float64) *domain.Geometry {
+	return &domain.Geometry{
+		Type: domain.GeometryPolygon,
+		Polygons: []domain.Polygon{{{
+			{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat},
+		}}},
+	}
+}
+
+// Пересечения короче шага 10 м, с которым раньше проверялся маршрут.
+func TestRouteCrossingsShortClips(t *testing.T) {
+	cases := []struct {
+		name     string
+		incident domain.Incident
+		route    domain.LineString
+		maxM     float64
+	}{
+		{
+			// Маршрут срезает угол квадрата на ~3 м
+			name:     "polygon corner",
+			incident: domain.Incident{Geometry: square(76.89, 43.24, 76.90, 43.25)},
+			route:    domain.LineString{{76.8990, 43.25098}, {76.9010, 43.24898}},
+			maxM:     6,
+		},
+		{
+			// Полоса шириной 4 м поперёк маршрута
+			name: "narrow line zone",
+			incident: domain.Incident{
+				Geometry:    &domain.Geometry{Type: domain.GeometryLineString, Line: domain.LineString{{76.9000, 43.2400}, {76.9000, 43.2500}}},
+				LineBufferM: 2,
+			},
+			route: domain.LineString{{76.8955, 43.2453}, {76.9045, 43.2453}},
+			maxM:  6,
+		},
+		{
+			// Круг радиусом 3 м в 2 м от маршрута
+			name:     "small circle",
+			incident: domain.Incident{Lat: 43.24 + 2/metersPerDegree, Lon: 76.90, RadiusM: 3},
+			route:    domain.LineString{{76.8955, 43.2400}, {76.9045, 43.2400}},
+			maxM:     7,
+		},
+	}
+
+	for _, c := range cases {
+		crossings, _, err := routeCrossings(routeIndex(c.incident), c.route, time.Now())
+		if err != nil {
+			t.Fatalf("%s: routeCrossings: %v", c.name, err)
+		}
+		if len(crossings) != 1 || len(crossings[0].Sections) != 1 {
+			t.Fatalf("%s: crossings = %+v, want one section", c.name, crossings)
+		}
+		s := crossings[0].Sections[0]
+		if inside := s.Exit.DistanceM - s.Entry.DistanceM; inside <= 0 || inside > c.maxM {
+			t.Fatalf("%s: section %+v is %.2f m long, want (0, %.0f] m", c.name, s, inside, c.maxM)
+		}
+	}
+}
+
+func TestRouteCrossingsSections(t *testing.T) {
+	// Маршрут входит в зону, выходит, возвращается и заканчивается внутри
+	index := routeIndex(domain.Incident{Lat: 43.24, Lon: 76.90, RadiusM: 500})
+	route := domain.LineString{{76.88, 43.24}, {76.92, 43.24}, {76.92, 43.2410}, {76.90, 43.2410}}
+
+	crossings, lengthM, err := routeCrossings(index, route, time.Now())
+	if err != nil {
+		t.Fatalf("routeCrossings: %v", err)
+	}
+	if len(crossings) != 1 || len(crossings[0].Sections) != 2 {
+		t.Fatalf("crossings = %+v, want two sections", crossings)
+	}
+
+	first, second := crossings[0].Sections[0], crossings[0].Sections[1]
+	if d := DistanceMeters(43.24, 76.90, first.Entry.Lat, first.Entry.Lon); d < 499 || d > 501 {
+		t.Errorf("entry is %.2f m from the centre, want 500", d)
+	}
+	if d := DistanceMeters(43.24, 76.90, first.Exit.Lat, first.Exit.Lon); d < 499 || d > 501 {
+		t.Errorf("exit is %.2f m from the centre, want 500", d)
+	}
+	if second.Exit.DistanceM != lengthM {
+		t.Errorf("last exit at %.2f m, want route end %.2f m", second.Exit.DistanceM, lengthM)
+	}
+}
+
+func TestRouteCrossingsWorkLimit(t *testing.T) {
+	ring := make(domain.Ring, 0, routeMaxWork+1)
+	for k := range routeMaxWork {
+		ring = append(ring, domain.Point{76.89 + 0.01*float64(k)/routeMaxWork, 43.24})
+	}
+	ring = append(ring, domain.Point{76.90, 43.25}, ring[0])
+	index := routeIndex(domain.Incident{Geometry: &domain.Geometry{Type: domain.GeometryPolygon, Polygons: []domain.Polygon{{ring}}}})
+
+	_, _, err := routeCrossings(index, domain.LineString{{76.88, 43.245}, {76.91, 43.245}}, time.Now())
+	if !errors.Is(err, ErrRouteTooComplex) {
+		t.Fatalf("routeCrossings error = %v, want ErrRouteTooComplex", err)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+// Активные подписки кешируются: они читаются на каждое событие.
+// Изменения с других реплик подхватываются по истечении TTL.
+const subscriptionCacheTTL = 15 * time.Second
+
+type SubscriptionService struct {
+	repo repository.SubscriptionRepository
+
+	mu       sync.Mutex
+	active   []domain.Subscription
+	loadedAt time.Time
+}
+
+func NewSubscriptionService(repo repository.SubscriptionRepository) *SubscriptionService {
+	return &SubscriptionService{repo: repo}
+}
+
+func (s *SubscriptionService) Create(subscription *domain.Subscription) error {
+	if subscription == nil {
+		return errors.New("subscription is nil")
+	}
+	if err := s.repo.Create(subscription); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *SubscriptionService) GetByID(id int64) (*domain.Subscription, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *SubscriptionService) List() ([]domain.Subscription, error) {
+	return s.repo.List()
+}
+
+func (s *SubscriptionService) Update(subscription *domain.Subscription) error {
+	if subscription == nil {
+		return errors.New("subscription is nil")
+	}
+	if err := s.repo.Update(subscription); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *SubscriptionService) Delete(id int64) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Active возвращает активные подписки из кеша.
+func (s *SubscriptionService) Active() ([]domain.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.loadedAt) < subscriptionCacheTTL {
+		return s.active, nil
+	}
+
+	active, err := s.repo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+
+	s.active = active
+	s.loadedAt = time.Now()
+	return s.active, nil
+}
+
+func (s *SubscriptionService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loadedAt = time.Time{}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+const (
+	// Предыдущая проверка старше этого не используется для оценки движения.
+	motionMaxAge = 2 * time.Minute
+	// При меньшем смещении пользователь считается стоящим на месте
+	// (погрешность GPS).
+	motionMinDistanceM = 5
+	// Быстрее движение считается ошибкой координат (~540 км/ч).
+	motionMaxSpeedMps = 150
+
+	// Бюджет работы на прогноз одной проверки (см. routeBudget): меньше,
+	// чем на маршрут, — прогноз считается для каждой позиции пачки.
+	trajectoryMaxWork = routeMaxWork / 20
+	// Точность уточнения точки входа в зону (и границ участков маршрута).
+	trajectoryPrecisionM = 1
+)
+
+// Motion — скорость (м/с) и курс (градусы от севера по часовой стрелке).
+type Motion struct {
+	SpeedMps   float64
+	HeadingDeg float64
+}
+
+// motionBetween оценивает движение по двум последовательным позициям.
+// false — позиции слишком далеко друг от друга по времени, пользователь
+// стоит на месте или скорость неправдоподобна.
+func motionBetween(dist DistanceCalculator, fromLat, fromLon float64, fromAt time.Time, toLat, toLon float64, toAt time.Time) (Motion, bool) {
+	dt := toAt.Sub(fromAt)
+	if dt <= 0 || dt > motionMaxAge {
+		return Motion{}, false
+	}
+
+	d := dist.Distance(fromLat, fromLon, toLat, toLon)
+	if d < motionMinDistanceM {
+		return Motion{}, false
+	}
+
+	speed := d / dt.Seconds()
+	if speed > motionMaxSpeedMps {
+		return Motion{}, false
+	}
+
+	return Motion{SpeedMps: speed, HeadingDeg: BearingDegrees(fromLat, fromLon, toLat, toLon)}, true
+}
+
+// predictIncidents возвращает зоны, в которые пользователь войдёт в течение
+// horizon, если продолжит движение по дуге большого круга с постоянной
+// скоростью: с путём до входа и прогнозируемым временем. Зоны, в которых
+// он уже находится, не возвращаются. Путь проверяется как отрезок
+// маршрута (incidentSpans), поэтому вход находится и в узкую зону. Зоны
+// перебираются от ближайших; когда бюджет работы исчерпан, возвращаются
+// найденные до этого.
+func predictIncidents(index *IncidentIndex, lat, lon float64, m Motion, horizon time.Duration) []domain.IncidentMatch {
+	pathM := m.SpeedMps * horizon.Seconds()
+	if pathM < motionMinDistanceM {
+		return nil
+	}
+
+	arc := newHeadingArc(lat, lon, m.HeadingDeg, pathM)
+	endLat, endLon := arc.point(pathM)
+	start, end := domain.Point{lon, lat}, domain.Point{endLon, endLat}
+
+	now := time.Now()
+	dist := index.Distance()
+	budget := &routeBudget{left: trajectoryMaxWork}
+
+	candidates := index.Near(lat, lon, pathM)
+	if budget.spend(len(candidates)) != nil {
+		return nil
+	}
+
+	type candidate struct {
+		incident domain.Incident
+		gapM     float64 // до описанной окружности
+	}
+	var nearest []candidate
+	for _, i := range candidates {
+		if !i.ActiveAt(now) {
+			continue
+		}
+		// Описанная окружность зоны не задевает путь
+		_, _, d := nearestOnSegment(Haversine{}, i.Lat, i.Lon, start, end)
+		if d > float64(i.RadiusM)*1.01+1 {
+			continue
+		}
+		nearest = append(nearest, candidate{i, DistanceMeters(lat, lon, i.Lat, i.Lon) - float64(i.RadiusM)})
+	}
+	sort.Slice(nearest, func(a, b int) bool {
+		if nearest[a].gapM != nearest[b].gapM {
+			return nearest[a].gapM < nearest[b].gapM
+		}
+		return nearest[a].incident.ID < nearest[b].incident.ID
+	})
+
+	var predicted []domain.IncidentMatch
+	for _, c := range nearest {
+		i := c.incident
+
+		spans, err := incidentSpans(dist, i, arc, budget)
+		if err != nil {
+			break
+		}
+		// Путь начинается внутри зоны — она уже в результате проверки
+		if len(spans) == 0 || spans[0].fromM == 0 {
+			continue
+		}
+
+		entryM := spans[0].fromM
+		eta := entryM / m.SpeedMps
+		predicted = append(predicted, domain.IncidentMatch{
+			Incident:   i,
+			Status:     domain.MatchPredicted,
+			DistanceM:  entryM,
+			ETASeconds: &eta,
+		})
+	}
+
+	sort.Slice(predicted, func(a, b int) bool {
+		if predicted[a].DistanceM != predicted[b].DistanceM {
+			return predicted[a].DistanceM < predicted[b].DistanceM
+		}
+		return predicted[a].ID < predicted[b].ID
+	})
+
+	return predicted
+}
+
+// withPredictions дополняет результат проверки прогнозом: зоне, к которой
+// пользователь уже приближается, добавляется время до входа, остальные
+// прогнозируемые зоны добавляются в конец.
+func withPredictions(matched, predicted []domain.IncidentMatch) []domain.IncidentMatch {
+	for _, p := range predicted {
+		pos := -1
+		for idx, m := range matched {
+			if m.ID == p.ID {
+				pos = idx
+				break
+			}
+		}
+
+		switch {
+		case pos < 0:
+			matched = append(matched, p)
+		case matched[pos].Status == domain.MatchApproaching:
+			matched[pos].ETASeconds = p.ETASeconds
+		}
+	}
+	return matched
+}
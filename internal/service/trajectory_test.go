@@ -0,0 +1,77 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// Движение на север от этой точки: 10 м/с, горизонт 2 минуты — 1200 м.
+const (
+	trajectoryLat = 43.24
+	trajectoryLon = 76.89
+)
+
+func northbound() Motion {
+	return Motion{SpeedMps: 10, HeadingDeg: 0}
+}
+
+// latAhead — широта точки в offsetM к северу от начала пути.
+func latAhead(offsetM float64) float64 {
+	lat, _ := Destination(trajectoryLat, trajectoryLon, 0, offsetM)
+	return lat
+}
+
+func TestPredictIncidentsThinZone(t *testing.T) {
+	// Полоса шириной ~3 м поперёк пути: при шаге 10 м её можно было пропустить
+	index := routeIndex(domain.Incident{
+		Title:    "Barrier",
+		Geometry: square(trajectoryLon-0.01, latAhead(503.5), trajectoryLon+0.01, latAhead(506.5)),
+	})
+
+	predicted := predictIncidents(index, trajectoryLat, trajectoryLon, northbound(), 2*time.Minute)
+	if len(predicted) != 1 {
+		t.Fatalf("predicted %d zones, want the thin one", len(predicted))
+	}
+
+	p := predicted[0]
+	if p.Status != domain.MatchPredicted || math.Abs(p.DistanceM-503.5) > 2 {
+		t.Fatalf("prediction = %s at %.1f m, want predicted at ~503.5 m", p.Status, p.DistanceM)
+	}
+	if p.ETASeconds == nil || math.Abs(*p.ETASeconds-p.DistanceM/10) > 1e-9 {
+		t.Fatalf("ETA = %v, want distance / speed", p.ETASeconds)
+	}
+}
+
+func TestPredictIncidentsSkipsZoneAroundUser(t *testing.T) {
+	index := routeIndex(domain.Incident{Title: "Fire", Lat: trajectoryLat, Lon: trajectoryLon, RadiusM: 100})
+
+	if predicted := predictIncidents(index, trajectoryLat, trajectoryLon, northbound(), 2*time.Minute); len(predicted) != 0 {
+		t.Fatalf("predicted %+v for a zone the user is already in", predicted)
+	}
+}
+
+func TestPredictIncidentsWorkLimit(t *testing.T) {
+	// Дальняя зона с числом вершин больше бюджета не задерживает проверку,
+	// а ближняя, проверенная раньше, остаётся в прогнозе
+	const vertices = trajectoryMaxWork + 1
+	ring := make(domain.Ring, 0, vertices+1)
+	centerLat := latAhead(900)
+	for k := range vertices {
+		a := 2 * math.Pi * float64(k) / vertices
+		ring = append(ring, domain.Point{trajectoryLon + 0.002*math.Sin(a), centerLat + 0.001*math.Cos(a)})
+	}
+	ring = append(ring, ring[0])
+
+	index := routeIndex(
+		domain.Incident{Title: "Near", Lat: latAhead(300), Lon: trajectoryLon, RadiusM: 50},
+		domain.Incident{Title: "Huge", Geometry: &domain.Geometry{Type: domain.GeometryPolygon, Polygons: []domain.Polygon{{ring}}}},
+	)
+
+	predicted := predictIncidents(index, trajectoryLat, trajectoryLon, northbound(), 2*time.Minute)
+	if len(predicted) != 1 || predicted[0].Title != "Near" {
+		t.Fatalf("predicted %d zones, want only the near one", len(predicted))
+	}
+}
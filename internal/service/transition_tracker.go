@@ -0,0 +1,125 @@
+package service
+
+import (
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+)
+
+// Transitions — изменения положения пользователя относительно зон
+// по сравнению с предыдущей проверкой. Approached — зоны, в буфер
+// приближения которых пользователь попал снаружи.
+type Transitions struct {
+	Entered    []int64
+	Exited     []int64
+	Dwelled    []int64
+	Approached []int64
+}
+
+func (t Transitions) Empty() bool {
+	return len(t.Entered) == 0 && len(t.Exited) == 0 && len(t.Dwelled) == 0 && len(t.Approached) == 0
+}
+
+// TransitionTracker хранит, в каких зонах (и в буферах приближения каких
+// зон) сейчас находится каждый пользователь, и вычисляет входы, выходы,
+// длительное нахождение и приближение.
+type TransitionTracker struct {
+	repo  repository.ZonePresenceRepository
+	dwell time.Duration
+}
+
+func NewTransitionTracker(repo repository.ZonePresenceRepository, dwell time.Duration) *TransitionTracker {
+	return &TransitionTracker{repo: repo, dwell: dwell}
+}
+
+// Track сравнивает зоны inside и approaching (буферы приближения) с
+// сохранённым состоянием пользователя на момент at и сохраняет новое
+// состояние. Переход из зоны в её буфер — выход, из буфера в зону — вход;
+// уход из буфера наружу событий не порождает. Чтение и запись состояния
+// атомарны (см. ZonePresenceRepository.Update), поэтому параллельные
+// проверки одного пользователя не порождают повторных событий. Проверка
+// старше уже учтённой (запоздавший элемент пачки) пропускается: сравнение
+// с более новым состоянием дало бы ложные выход и повторный вход.
+func (t *TransitionTracker) Track(userID string, inside, approaching []int64, at time.Time) (Transitions, error) {
+	var result Transitions
+
+	_, err := t.repo.Update(userID, at, func(previous []domain.ZonePresence) ([]domain.ZonePresence, []int64) {
+		var (
+			upsert  []domain.ZonePresence
+			removed []int64
+		)
+		result, upsert, removed = t.diff(userID, previous, inside, approaching, at)
+		return upsert, removed
+	})
+	if err != nil {
+		return Transitions{}, err
+	}
+
+	return result, nil
+}
+
+// diff вычисляет переходы и новое состояние пользователя по previous.
+func (t *TransitionTracker) diff(
+	userID string,
+	previous []domain.ZonePresence,
+	inside, approaching []int64,
+	at time.Time,
+) (Transitions, []domain.ZonePresence, []int64) {
+	known := make(map[int64]domain.ZonePresence, len(previous))
+	for _, p := range previous {
+		known[p.IncidentID] = p
+	}
+
+	var (
+		result Transitions
+		upsert []domain.ZonePresence
+	)
+
+	for _, id := range inside {
+		p, ok := known[id]
+		delete(known, id)
+
+		if !ok || !p.Inside {
+			p = domain.ZonePresence{UserID: userID, IncidentID: id, EnteredAt: at, Inside: true}
+			result.Entered = append(result.Entered, id)
+		}
+
+		p.LastSeenAt = at
+
+		if !p.DwellNotified && t.dwell > 0 && at.Sub(p.EnteredAt) >= t.dwell {
+			p.DwellNotified = true
+			result.Dwelled = append(result.Dwelled, id)
+		}
+
+		upsert = append(upsert, p)
+	}
+
+	for _, id := range approaching {
+		p, ok := known[id]
+		delete(known, id)
+
+		switch {
+		case !ok:
+			p = domain.ZonePresence{UserID: userID, IncidentID: id, EnteredAt: at}
+			result.Approached = append(result.Approached, id)
+		case p.Inside:
+			p = domain.ZonePresence{UserID: userID, IncidentID: id, EnteredAt: at}
+			result.Exited = append(result.Exited, id)
+		}
+
+		p.LastSeenAt = at
+		upsert = append(upsert, p)
+	}
+
+	// Всё, что осталось в known, — зоны, которые пользователь покинул
+	var removed []int64
+	for id, p := range known {
+		removed = append(removed, id)
+		if p.Inside {
+			result.Exited = append(result.Exited, id)
+		}
+	}
+
+	return result, upsert, removed
+}
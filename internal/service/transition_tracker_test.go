@@ -0,0 +1,147 @@
+package service
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+)
+
+// memoryPresence — ZonePresenceRepository в памяти с той же гарантией
+// атомарности Update, что и у Postgres-реализации.
+type memoryPresence struct {
+	mu       sync.Mutex
+	users    map[string]map[int64]domain.ZonePresence
+	lastSeen map[string]time.Time
+}
+
+func (m *memoryPresence) Update(
+	userID string,
+	at time.Time,
+	fn func(previous []domain.ZonePresence) ([]domain.ZonePresence, []int64),
+) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.users == nil {
+		m.users = make(map[string]map[int64]domain.ZonePresence)
+		m.lastSeen = make(map[string]time.Time)
+	}
+	if seen, ok := m.lastSeen[userID]; ok && at.Before(seen) {
+		return false, nil
+	}
+	m.lastSeen[userID] = at
+	current := m.users[userID]
+
+	var previous []domain.ZonePresence
+	for _, p := range current {
+		previous = append(previous, p)
+	}
+
+	upsert, removed := fn(previous)
+
+	if current == nil {
+		current = make(map[int64]domain.ZonePresence)
+		m.users[userID] = current
+	}
+	for _, p := range upsert {
+		current[p.IncidentID] = p
+	}
+	for _, id := range removed {
+		delete(current, id)
+	}
+	return true, nil
+}
+
+func TestTrackConcurrentEntry(t *testing.T) {
+	tracker := NewTransitionTracker(&memoryPresence{}, 0)
+	at := time.Now().UTC()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		entered int
+	)
+	for range 20 {
+		wg.Go(func() {
+			tr, err := tracker.Track("truck-1", []int64{7}, nil, at)
+			if err != nil {
+				t.Errorf("Track: %v", err)
+				return
+			}
+			mu.Lock()
+			entered += len(tr.Entered)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if entered != 1 {
+		t.Fatalf("concurrent checks produced %d entered events, want 1", entered)
+	}
+}
+
+func TestTrackTransitions(t *testing.T) {
+	tracker := NewTransitionTracker(&memoryPresence{}, 10*time.Minute)
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name                string
+		inside, approaching []int64
+		at                  time.Time
+		want                Transitions
+	}{
+		{"approach", nil, []int64{1}, start, Transitions{Approached: []int64{1}}},
+		{"enter from buffer", []int64{1}, nil, start.Add(time.Minute), Transitions{Entered: []int64{1}}},
+		{"stay", []int64{1}, nil, start.Add(5 * time.Minute), Transitions{}},
+		{"dwell", []int64{1}, nil, start.Add(11 * time.Minute), Transitions{Dwelled: []int64{1}}},
+		{"exit to buffer", nil, []int64{1}, start.Add(12 * time.Minute), Transitions{Exited: []int64{1}}},
+		{"leave buffer", nil, nil, start.Add(13 * time.Minute), Transitions{}},
+		{"enter directly", []int64{1}, nil, start.Add(14 * time.Minute), Transitions{Entered: []int64{1}}},
+		{"leave zone", nil, nil, start.Add(15 * time.Minute), Transitions{Exited: []int64{1}}},
+	}
+
+	for _, s := range steps {
+		got, err := tracker.Track("truck-1", s.inside, s.approaching, s.at)
+		if err != nil {
+			t.Fatalf("%s: Track: %v", s.name, err)
+		}
+		if !slices.Equal(got.Entered, s.want.Entered) || !slices.Equal(got.Exited, s.want.Exited) ||
+			!slices.Equal(got.Dwelled, s.want.Dwelled) || !slices.Equal(got.Approached, s.want.Approached) {
+			t.Fatalf("%s: Track = %+v, want %+v", s.name, got, s.want)
+		}
+	}
+}
+
+func TestTrackSkipsOutOfOrderReports(t *testing.T) {
+	tracker := NewTransitionTracker(&memoryPresence{}, 0)
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	// Пользователь вошёл и вышел; затем приходит запоздавшая позиция из
+	// середины — она не должна дать ни повторного входа, ни выхода
+	steps := []struct {
+		name   string
+		inside []int64
+		at     time.Time
+		want   Transitions
+	}{
+		{"enter", []int64{1}, start, Transitions{Entered: []int64{1}}},
+		{"leave", nil, start.Add(2 * time.Minute), Transitions{Exited: []int64{1}}},
+		{"late inside", []int64{1}, start.Add(time.Minute), Transitions{}},
+		{"late outside", nil, start.Add(90 * time.Second), Transitions{}},
+		{"enter again", []int64{1}, start.Add(3 * time.Minute), Transitions{Entered: []int64{1}}},
+		{"late outside after entry", nil, start.Add(150 * time.Second), Transitions{}},
+	}
+
+	for _, s := range steps {
+		got, err := tracker.Track("truck-1", s.inside, nil, s.at)
+		if err != nil {
+			t.Fatalf("%s: Track: %v", s.name, err)
+		}
+		if !slices.Equal(got.Entered, s.want.Entered) || !slices.Equal(got.Exited, s.want.Exited) {
+			t.Fatalf("%s: Track = %+v, want %+v", s.name, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
+	"github.com/kassse1/geo-alert-core/pkg/webhook"
+)
+
+type WebhookDispatcherConfig struct {
+	// Secret — общий секрет для подписи HMAC-SHA256 доставок на WEBHOOK_URL;
+	// у подписчиков свой секрет. Пустой — без подписи.
+	Secret  string
+	Workers int
+	// PerSubscription — максимум одновременных отправок одному подписчику,
+	// чтобы медленный получатель не занимал всех воркеров.
+	PerSubscription int
+	MaxAttempts     int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+	PollInterval    time.Duration
+	// Lease — на сколько запись «занимается» воркером; если процесс упадёт
+	// во время отправки, запись снова станет доступной по истечении lease.
+	Lease time.Duration
+}
+
+// WebhookDispatcher забирает записи из outbox и отправляет их с
+// экспоненциальной задержкой и jitter между попытками. Записи, которые
+// так и не удалось доставить, переводятся в статус dead.
+type WebhookDispatcher struct {
+	outbox repository.WebhookOutboxRepository
+	client *http.Client
+	cfg    WebhookDispatcherConfig
+
+	slots chan struct{}
+	freed chan struct{}
+
+	mu       sync.Mutex
+	inFlight map[int64]int
+}
+
+func NewWebhookDispatcher(
+	outbox repository.WebhookOutboxRepository,
+	cfg WebhookDispatcherConfig,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		outbox:   outbox,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		cfg:      cfg,
+		slots:    make(chan struct{}, cfg.Workers),
+		freed:    make(chan struct{}, 1),
+		inFlight: make(map[int64]int),
+	}
+}
+
+// Run работает до отмены ctx; начатые отправки дожидаются завершения.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		claimed := 0
+		if free := cap(d.slots) - len(d.slots); free > 0 {
+			claimed = d.dispatch(free, &wg)
+		}
+
+		// Забрали всё, что могли, — сразу пробуем ещё раз
+		if claimed > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.freed:
+		}
+	}
+}
+
+// dispatch забирает до limit записей и запускает их отправку.
+func (d *WebhookDispatcher) dispatch(limit int, wg *sync.WaitGroup) int {
+	deliveries, err := d.outbox.ClaimDue(limit, d.cfg.PerSubscription, d.busySubscriptions(), d.cfg.Lease)
+	if err != nil {
+		log.Println("webhook outbox claim error:", err)
+		return 0
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		key := subscriptionKey(delivery)
+
+		d.slots <- struct{}{}
+		d.mu.Lock()
+		d.inFlight[key]++
+		d.mu.Unlock()
+
+		wg.Go(func() {
+			d.deliver(delivery)
+
+			d.mu.Lock()
+			if d.inFlight[key]--; d.inFlight[key] == 0 {
+				delete(d.inFlight, key)
+			}
+			d.mu.Unlock()
+			<-d.slots
+
+			select {
+			case d.freed <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	return len(deliveries)
+}
+
+// busySubscriptions — подписчики, исчерпавшие лимит одновременных отправок.
+func (d *WebhookDispatcher) busySubscriptions() []int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var busy []int64
+	for key, n := range d.inFlight {
+		if n >= d.cfg.PerSubscription {
+			busy = append(busy, key)
+		}
+	}
+	return busy
+}
+
+func subscriptionKey(delivery *domain.WebhookDelivery) int64 {
+	if delivery.SubscriptionID == nil {
+		return 0
+	}
+	return *delivery.SubscriptionID
+}
+
+func (d *WebhookDispatcher) deliver(delivery *domain.WebhookDelivery) {
+	delivery.Attempts++
+
+	start := time.Now()
+	statusCode, err := d.post(delivery)
+
+	attempt := domain.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		StatusCode: statusCode,
+		Duration:   time.Since(start),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = attempt.Error
+
+	var retryIn time.Duration
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliveryDelivered
+	case isPermanentFailure(statusCode) || delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = domain.DeliveryDead
+		log.Printf("webhook delivery %d dead after %d attempts: %v", delivery.ID, delivery.Attempts, err)
+	default:
+		delivery.Status = domain.DeliveryPending
+		retryIn = d.backoff(delivery.Attempts)
+	}
+
+	if err := d.outbox.RecordAttempt(delivery, attempt, retryIn); err != nil {
+		log.Println("webhook outbox record error:", err)
+	}
+}
+
+func (d *WebhookDispatcher) post(delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderDeliveryID, delivery.UID)
+
+	secret := d.cfg.Secret
+	if delivery.SubscriptionID != nil {
+		secret = delivery.Secret
+	}
+
+	if secret != "" {
+		// Подпись считается заново на каждой попытке — со свежим timestamp
+		ts := time.Now().Unix()
+		req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(webhook.HeaderSignature, webhook.Sign(secret, ts, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff: base * 2^(attempt-1), ограниченная MaxBackoff, со случайным
+// разбросом в верхней половине интервала.
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff << min(attempt-1, 30)
+	if delay <= 0 || delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// 4xx (кроме 408 и 429) означает, что повтор не поможет.
+func isPermanentFailure(statusCode int) bool {
+	return statusCode >= 400 && statusCode < 500 &&
+		statusCode != http.StatusRequestTimeout &&
+		statusCode != http.StatusTooManyRequests
+}
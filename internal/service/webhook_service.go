@@ -1,55 +1,89 @@
 package service
 
 import (
-	"bytes"
 	"encoding/json"
-	"log"
-	"net/http"
+	"errors"
 	"time"
 
 	"github.com/kassse1/geo-alert-core/internal/domain"
+	"github.com/kassse1/geo-alert-core/internal/repository"
 )
 
+// WebhookService ставит вебхуки в outbox — по одной записи на каждого
+// подходящего получателя; отправкой занимается WebhookDispatcher.
 type WebhookService struct {
-	url string
+	url           string
+	outbox        repository.WebhookOutboxRepository
+	subscriptions *SubscriptionService
 }
 
-func NewWebhookService(url string) *WebhookService {
-	return &WebhookService{url: url}
+func NewWebhookService(
+	url string,
+	outbox repository.WebhookOutboxRepository,
+	subscriptions *SubscriptionService,
+) *WebhookService {
+	return &WebhookService{
+		url:           url,
+		outbox:        outbox,
+		subscriptions: subscriptions,
+	}
 }
 
-func (w *WebhookService) Send(userID string, incidents []domain.Incident) {
-	if w.url == "" {
-		return
-	}
+// Publish рассылает событие: общему WEBHOOK_URL — целиком, подписчикам —
+// только инциденты, прошедшие их фильтры.
+func (w *WebhookService) Publish(eventType, userID string, incidents []domain.Incident) error {
+	var errs []error
 
-	payload := map[string]interface{}{
-		"user_id":   userID,
-		"incidents": incidents,
-		"sent_at":   time.Now(),
+	if w.url != "" {
+		errs = append(errs, w.enqueue(nil, w.url, eventType, userID, incidents))
 	}
 
-	data, err := json.Marshal(payload)
+	subscriptions, err := w.subscriptions.Active()
 	if err != nil {
-		log.Println("webhook marshal error:", err)
-		return
+		return errors.Join(append(errs, err)...)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewBuffer(data))
-	if err != nil {
-		log.Println("webhook request error:", err)
-		return
+	for _, s := range subscriptions {
+		if !s.WantsEvent(eventType) {
+			continue
+		}
+
+		matched := make([]domain.Incident, 0, len(incidents))
+		for _, i := range incidents {
+			if s.WantsIncident(i) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		errs = append(errs, w.enqueue(&s.ID, s.URL, eventType, userID, matched))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return errors.Join(errs...)
+}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+func (w *WebhookService) enqueue(
+	subscriptionID *int64,
+	url, eventType, userID string,
+	incidents []domain.Incident,
+) error {
+	payload := map[string]interface{}{
+		"event":     eventType,
+		"user_id":   userID,
+		"incidents": incidents,
+		"sent_at":   time.Now(),
+	}
+
+	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Println("webhook send error:", err)
-		return
+		return err
 	}
-	defer resp.Body.Close()
 
-	log.Println("webhook sent, status:", resp.StatusCode)
+	return w.outbox.Enqueue(&domain.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		URL:            url,
+		Payload:        data,
+	})
 }
@@ -1,7 +1,12 @@
 package transport
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/kassse1/geo-alert-core/internal/config"
 	"github.com/kassse1/geo-alert-core/internal/handler"
@@ -11,27 +16,148 @@ import (
 	"github.com/kassse1/geo-alert-core/pkg/postgres"
 )
 
-func NewRouter(db *postgres.DB, cfg *config.Config) http.Handler {
+// NewRouter собирает зависимости и маршруты. Фоновые задачи (синхронизация
+// индекса инцидентов, отправка вебхуков) работают до отмены ctx и
+// регистрируются в wg, чтобы main мог дождаться их завершения.
+func NewRouter(
+	ctx context.Context,
+	db *postgres.DB,
+	cfg *config.Config,
+	wg *sync.WaitGroup,
+) (http.Handler, error) {
 	mux := http.NewServeMux()
 
 	// ---------- Repositories ----------
-	incidentRepo := repository.NewIncidentPostgresRepository(db.DB)
+	var (
+		incidentRepo repository.IncidentRepository = repository.NewIncidentPostgresRepository(db.DB)
+		spatialRepo  repository.SpatialIncidentRepository
+	)
+	if cfg.IncidentStore == config.IncidentStorePostGIS {
+		postgisRepo := repository.NewIncidentPostGISRepository(db.DB)
+		incidentRepo, spatialRepo = postgisRepo, postgisRepo
+
+		// Без PostGIS или migrations/postgis запрос не выполнится — лучше
+		// узнать об этом при старте, а не на каждой проверке
+		if _, err := spatialRepo.ActiveContaining(0, 0); err != nil {
+			return nil, fmt.Errorf("postgis incident store: %w", err)
+		}
+	}
 	checkRepo := repository.NewLocationCheckPostgresRepository(db.DB)
+	versionRepo := repository.NewIncidentVersionPostgresRepository(db.DB)
+	outboxRepo := repository.NewWebhookOutboxPostgresRepository(db.DB)
+	subscriptionRepo := repository.NewSubscriptionPostgresRepository(db.DB)
+	presenceRepo := repository.NewZonePresencePostgresRepository(db.DB)
+	positionRepo := repository.NewUserPositionPostgresRepository(db.DB)
 
 	// ---------- Services ----------
+	var distance service.DistanceCalculator = service.Haversine{}
+	if cfg.DistanceMethod == config.DistanceVincenty {
+		distance = service.Vincenty{}
+	}
+
+	incidentIndex := service.NewIncidentIndex(cfg.ApproachBufferM, distance)
+
 	incidentService := service.NewIncidentService(
 		incidentRepo,
 		checkRepo,
+		versionRepo,
+		incidentIndex,
+	)
+
+	// Индекс загружается и с PostGIS: по нему работают ближайшие зоны,
+	// маршруты, прогноз, поток событий и запасной поиск при ошибке БД
+	if err := incidentService.LoadIndex(); err != nil {
+		return nil, err
+	}
+
+	// Изменения инцидентов с других реплик приходят через LISTEN/NOTIFY
+	incidentListener := postgres.NewListener(cfg.PostgresDSN, repository.IncidentChangesChannel)
+	incidentChanges := incidentListener.Subscribe()
+	wg.Go(func() { incidentService.SyncIndex(ctx, incidentChanges) })
+	wg.Go(func() { incidentListener.Run(ctx) })
+
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo)
+
+	webhookService := service.NewWebhookService(
+		cfg.WebhookURL,
+		outboxRepo,
+		subscriptionService,
 	)
 
-	webhookService := service.NewWebhookService(cfg.WebhookURL)
+	webhookDispatcher := service.NewWebhookDispatcher(outboxRepo, service.WebhookDispatcherConfig{
+		Secret:          cfg.WebhookSecret,
+		Workers:         cfg.WebhookWorkers,
+		PerSubscription: max(1, cfg.WebhookWorkers/4),
+		MaxAttempts:     cfg.WebhookMaxAttempts,
+		BaseBackoff:     time.Duration(cfg.WebhookRetryBaseSeconds) * time.Second,
+		MaxBackoff:      time.Duration(cfg.WebhookRetryMaxSeconds) * time.Second,
+		PollInterval:    time.Second,
+		Lease:           time.Minute,
+	})
+	wg.Go(func() { webhookDispatcher.Run(ctx) })
+
+	// Начало и истечение окна действия инцидентов (starts_at/expires_at)
+	scheduleInterval := time.Duration(cfg.ScheduleIntervalSeconds) * time.Second
+	wg.Go(func() { incidentService.RunSchedule(ctx, scheduleInterval, webhookService) })
+
+	// Кулдаун уведомлений (пользователь, инцидент) перед вебхуками
+	var alertPublisher service.AlertPublisher = webhookService
+
+	var cooldownStore repository.AlertCooldownRepository
+
+	switch cfg.AlertCooldownStore {
+	case config.CooldownStorePostgres:
+		cooldownStore = repository.NewAlertCooldownPostgresRepository(db.DB)
+	case config.CooldownStoreMemory:
+		cooldownStore = repository.NewAlertCooldownMemoryRepository()
+	}
+
+	if cooldownStore != nil {
+		dedupPublisher := service.NewDedupPublisher(
+			webhookService,
+			cooldownStore,
+			time.Duration(cfg.AlertCooldownSeconds)*time.Second,
+		)
+		wg.Go(func() { dedupPublisher.RunPrune(ctx) })
+		alertPublisher = dedupPublisher
+	}
+
+	var transitionTracker *service.TransitionTracker
+	if cfg.AlertMode == config.AlertModeTransitions {
+		transitionTracker = service.NewTransitionTracker(
+			presenceRepo,
+			time.Duration(cfg.DwellMinutes)*time.Minute,
+		)
+	}
+
+	// Поток событий: позиции из проверок (в том числе на других репликах)
+	// и изменения инцидентов
+	alertHub := service.NewAlertHub(incidentIndex, positionRepo)
+	incidentService.OnChange(alertHub.IncidentChanged)
+	wg.Go(func() { alertHub.Run(ctx) })
+
+	positionListener := postgres.NewListener(cfg.PostgresDSN, repository.UserPositionsChannel)
+	positions := positionListener.Subscribe()
+	wg.Go(func() { alertHub.SyncPositions(ctx, positions) })
+	wg.Go(func() { positionListener.Run(ctx) })
 
 	locationService := service.NewLocationService(
-		incidentRepo,
+		incidentIndex,
+		spatialRepo,
+		cfg.ApproachBufferM,
 		checkRepo,
-		webhookService,
+		alertPublisher,
+		transitionTracker,
+		alertHub,
+		time.Duration(cfg.BroadcastWindowMinutes)*time.Minute,
+		time.Duration(cfg.PredictionHorizonSeconds)*time.Second,
 	)
 
+	// Пользователи внутри новой зоны получают уведомление сразу (в фоне,
+	// не задерживая запрос, изменивший инцидент)
+	incidentService.OnChange(locationService.IncidentChanged)
+	wg.Go(func() { locationService.RunBroadcasts(ctx) })
+
 	// ---------- Handlers ----------
 	incidentHandler := handler.NewIncidentHandler(
 		incidentService,
@@ -40,24 +166,63 @@ func NewRouter(db *postgres.DB, cfg *config.Config) http.Handler {
 
 	locationHandler := handler.NewLocationHandler(locationService)
 
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+
 	// ---------- Public ----------
 	mux.HandleFunc("/api/v1/location/check", locationHandler.Check)
+	mux.HandleFunc("/api/v1/location/check/batch", locationHandler.CheckBatch)
+	mux.HandleFunc("/api/v1/location/stream", locationHandler.Stream)
+	mux.HandleFunc("/api/v1/location/nearby", locationHandler.Nearby)
+	mux.HandleFunc("/api/v1/route/check", locationHandler.CheckRoute)
 	mux.HandleFunc("/api/v1/system/health", handler.Health)
 
+	// ---------- Location check history (audit) ----------
+	mux.Handle(
+		"/api/v1/location/checks",
+		middleware.APIKeyMiddleware(
+			cfg.APIKeys,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				locationHandler.History(w, r)
+			}),
+		),
+	)
+
 	// ---------- Incidents stats (MUST BE BEFORE /{id}) ----------
 	mux.Handle(
 		"/api/v1/incidents/stats",
 		middleware.APIKeyMiddleware(
-			cfg.APIKey,
+			cfg.APIKeys,
 			http.HandlerFunc(incidentHandler.Stats),
 		),
 	)
 
+	// ---------- Incidents GeoJSON import/export (MUST BE BEFORE /{id}) ----------
+	mux.Handle(
+		"/api/v1/incidents/geojson",
+		middleware.APIKeyMiddleware(
+			cfg.APIKeys,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					incidentHandler.ExportGeoJSON(w, r)
+				case http.MethodPost:
+					incidentHandler.ImportGeoJSON(w, r)
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				}
+			}),
+		),
+	)
+
 	// ---------- Incidents collection ----------
 	mux.Handle(
 		"/api/v1/incidents",
 		middleware.APIKeyMiddleware(
-			cfg.APIKey,
+			cfg.APIKeys,
 			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				switch r.Method {
 				case http.MethodPost:
@@ -75,13 +240,39 @@ func NewRouter(db *postgres.DB, cfg *config.Config) http.Handler {
 	mux.Handle(
 		"/api/v1/incidents/",
 		middleware.APIKeyMiddleware(
-			cfg.APIKey,
+			cfg.APIKeys,
 			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/history"):
+					if r.Method != http.MethodGet {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+					incidentHandler.History(w, r)
+					return
+				case strings.HasSuffix(r.URL.Path, "/activate"):
+					if r.Method != http.MethodPost {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+					incidentHandler.Activate(w, r)
+					return
+				case strings.HasSuffix(r.URL.Path, "/deactivate"):
+					if r.Method != http.MethodPost {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+					incidentHandler.Deactivate(w, r)
+					return
+				}
+
 				switch r.Method {
 				case http.MethodGet:
 					incidentHandler.GetByID(w, r)
 				case http.MethodPut:
 					incidentHandler.Update(w, r)
+				case http.MethodPatch:
+					incidentHandler.Patch(w, r)
 				case http.MethodDelete:
 					incidentHandler.Deactivate(w, r)
 				default:
@@ -91,5 +282,60 @@ func NewRouter(db *postgres.DB, cfg *config.Config) http.Handler {
 		),
 	)
 
-	return mux
+	// ---------- Admin: hard delete of incidents ----------
+	mux.Handle(
+		"/api/v1/admin/incidents/",
+		middleware.APIKeyMiddleware(
+			cfg.APIKeys,
+			middleware.AdminMiddleware(
+				cfg.APIAdmins,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != http.MethodDelete {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+					incidentHandler.Purge(w, r)
+				}),
+			),
+		),
+	)
+
+	// ---------- Webhook subscriptions ----------
+	mux.Handle(
+		"/api/v1/subscriptions",
+		middleware.APIKeyMiddleware(
+			cfg.APIKeys,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					subscriptionHandler.Create(w, r)
+				case http.MethodGet:
+					subscriptionHandler.List(w, r)
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				}
+			}),
+		),
+	)
+
+	mux.Handle(
+		"/api/v1/subscriptions/",
+		middleware.APIKeyMiddleware(
+			cfg.APIKeys,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					subscriptionHandler.GetByID(w, r)
+				case http.MethodPut:
+					subscriptionHandler.Update(w, r)
+				case http.MethodDelete:
+					subscriptionHandler.Delete(w, r)
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				}
+			}),
+		),
+	)
+
+	return mux, nil
 }
@@ -0,0 +1,5 @@
+ALTER TABLE incidents
+    ADD COLUMN geometry JSONB;
+
+COMMENT ON COLUMN incidents.geometry IS
+    'GeoJSON Polygon/MultiPolygon; NULL means a circle defined by lat, lon, radius_m';
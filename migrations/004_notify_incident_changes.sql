@@ -0,0 +1,22 @@
+CREATE OR REPLACE FUNCTION notify_incident_change() RETURNS trigger AS $$
+DECLARE
+    incident_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        incident_id := OLD.id;
+    ELSE
+        incident_id := NEW.id;
+    END IF;
+
+    PERFORM pg_notify(
+        'incident_changes',
+        json_build_object('id', incident_id, 'op', TG_OP)::text
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER incidents_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON incidents
+    FOR EACH ROW EXECUTE FUNCTION notify_incident_change();
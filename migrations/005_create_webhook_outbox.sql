@@ -0,0 +1,27 @@
+CREATE TABLE webhook_deliveries (
+                                    id BIGSERIAL PRIMARY KEY,
+                                    url TEXT NOT NULL,
+                                    payload JSONB NOT NULL,
+                                    status TEXT NOT NULL DEFAULT 'pending',
+                                    attempts INTEGER NOT NULL DEFAULT 0,
+                                    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+                                    last_status_code INTEGER,
+                                    last_error TEXT,
+                                    created_at TIMESTAMP NOT NULL DEFAULT now(),
+                                    delivered_at TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries(status);
+
+CREATE TABLE webhook_delivery_attempts (
+                                           id BIGSERIAL PRIMARY KEY,
+                                           delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+                                           attempt INTEGER NOT NULL,
+                                           status_code INTEGER,
+                                           error TEXT,
+                                           duration_ms INTEGER NOT NULL,
+                                           attempted_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);
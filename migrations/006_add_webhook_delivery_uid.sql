@@ -0,0 +1,6 @@
+-- Уникальный ID доставки, передаётся получателю в заголовке X-Geo-Alert-Delivery
+-- и не меняется между повторными попытками.
+ALTER TABLE webhook_deliveries
+    ADD COLUMN delivery_uid UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_delivery_uid ON webhook_deliveries(delivery_uid);
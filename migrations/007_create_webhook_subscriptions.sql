@@ -0,0 +1,22 @@
+CREATE TABLE webhook_subscriptions (
+                                       id BIGSERIAL PRIMARY KEY,
+                                       url TEXT NOT NULL,
+                                       secret TEXT NOT NULL DEFAULT '',
+                                       event_types TEXT[] NOT NULL DEFAULT '{}',
+                                       incident_ids BIGINT[] NOT NULL DEFAULT '{}',
+                                       bbox_min_lat DOUBLE PRECISION,
+                                       bbox_min_lon DOUBLE PRECISION,
+                                       bbox_max_lat DOUBLE PRECISION,
+                                       bbox_max_lon DOUBLE PRECISION,
+                                       min_severity TEXT NOT NULL DEFAULT '',
+                                       active BOOLEAN NOT NULL DEFAULT TRUE,
+                                       created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_subscriptions_active ON webhook_subscriptions(active);
+
+-- NULL — доставка на общий WEBHOOK_URL из конфигурации
+ALTER TABLE webhook_deliveries
+    ADD COLUMN subscription_id BIGINT REFERENCES webhook_subscriptions(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id);
@@ -0,0 +1,13 @@
+-- Текущее положение пользователя относительно зон: строка есть, пока
+-- пользователь находится внутри инцидента. Используется для событий
+-- входа/выхода/нахождения в зоне вместо вебхука на каждую проверку.
+CREATE TABLE user_zone_presence (
+                                    user_id TEXT NOT NULL,
+                                    incident_id BIGINT NOT NULL,
+                                    entered_at TIMESTAMP NOT NULL,
+                                    last_seen_at TIMESTAMP NOT NULL,
+                                    dwell_notified BOOLEAN NOT NULL DEFAULT FALSE,
+                                    PRIMARY KEY (user_id, incident_id)
+);
+
+CREATE INDEX idx_user_zone_presence_incident_id ON user_zone_presence(incident_id);
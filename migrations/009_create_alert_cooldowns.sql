@@ -0,0 +1,10 @@
+-- Момент последнего уведомления пары (пользователь, инцидент) —
+-- общий для всех реплик кулдаун вебхуков.
+CREATE TABLE alert_cooldowns (
+                                 user_id TEXT NOT NULL,
+                                 incident_id BIGINT NOT NULL,
+                                 notified_at TIMESTAMP NOT NULL DEFAULT now(),
+                                 PRIMARY KEY (user_id, incident_id)
+);
+
+CREATE INDEX idx_alert_cooldowns_notified_at ON alert_cooldowns(notified_at);
@@ -0,0 +1,8 @@
+-- Результат проверки: какие зоны совпали, была ли опасность и расстояние
+-- до центра ближайшей из совпавших зон (NULL, если совпадений нет).
+ALTER TABLE location_checks
+    ADD COLUMN incident_ids BIGINT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN has_danger BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN distance_m INTEGER;
+
+CREATE INDEX idx_location_checks_incident_ids ON location_checks USING GIN (incident_ids);
@@ -0,0 +1,10 @@
+-- Уровень опасности и категория инцидента. Существующие зоны получают
+-- значения по умолчанию (warning / other).
+ALTER TABLE incidents
+    ADD COLUMN severity TEXT NOT NULL DEFAULT 'warning'
+        CHECK (severity IN ('info', 'warning', 'critical')),
+    ADD COLUMN category TEXT NOT NULL DEFAULT 'other'
+        CHECK (category IN ('fire', 'flood', 'police', 'road', 'weather', 'medical', 'chemical', 'other'));
+
+CREATE INDEX idx_incidents_severity ON incidents(severity);
+CREATE INDEX idx_incidents_category ON incidents(category);
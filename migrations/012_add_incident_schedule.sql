@@ -0,0 +1,10 @@
+-- Окно действия инцидента (UTC). NULL — без ограничения с соответствующей стороны.
+ALTER TABLE incidents
+    ADD COLUMN starts_at TIMESTAMP,
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD CONSTRAINT incidents_schedule_check
+        CHECK (starts_at IS NULL OR expires_at IS NULL OR expires_at > starts_at);
+
+-- Для фоновой задачи: поиск истёкших и только что начавшихся зон
+CREATE INDEX idx_incidents_expires_at ON incidents(expires_at) WHERE active AND expires_at IS NOT NULL;
+CREATE INDEX idx_incidents_starts_at ON incidents(starts_at) WHERE active AND starts_at IS NOT NULL;
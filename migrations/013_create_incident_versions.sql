@@ -0,0 +1,94 @@
+-- Кто и когда последним изменил инцидент (идентификатор API-ключа)
+ALTER TABLE incidents
+    ADD COLUMN updated_by TEXT NOT NULL DEFAULT '',
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+UPDATE incidents SET updated_at = created_at;
+
+-- Неизменяемая история версий: полный снимок строки после изменения
+-- (для DELETE — до удаления) и разница с предыдущей версией.
+CREATE TABLE incident_versions (
+                                   id BIGSERIAL PRIMARY KEY,
+                                   incident_id BIGINT NOT NULL,
+                                   version INTEGER NOT NULL,
+                                   op TEXT NOT NULL,
+                                   actor TEXT NOT NULL DEFAULT '',
+                                   changed_at TIMESTAMP NOT NULL DEFAULT now(),
+                                   snapshot JSONB NOT NULL,
+                                   diff JSONB NOT NULL DEFAULT '{}',
+                                   UNIQUE (incident_id, version)
+);
+
+CREATE INDEX idx_incident_versions_changed_at ON incident_versions(incident_id, changed_at);
+
+INSERT INTO incident_versions (incident_id, version, op, actor, changed_at, snapshot)
+SELECT id, 1, 'create', '', created_at, to_jsonb(incidents)
+FROM incidents;
+
+CREATE OR REPLACE FUNCTION record_incident_version() RETURNS trigger AS $$
+DECLARE
+    row_id     BIGINT;
+    next_op    TEXT;
+    who        TEXT;
+    changes    JSONB := '{}';
+    row_data   JSONB;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        row_id := NEW.id;
+        next_op := 'create';
+        who := NEW.updated_by;
+        row_data := to_jsonb(NEW);
+    ELSIF TG_OP = 'UPDATE' THEN
+        row_id := NEW.id;
+        who := NEW.updated_by;
+        row_data := to_jsonb(NEW);
+
+        SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('from', o.value, 'to', n.value)), '{}')
+        INTO changes
+        FROM jsonb_each(row_data) n
+        JOIN jsonb_each(to_jsonb(OLD)) o USING (key)
+        WHERE n.value IS DISTINCT FROM o.value
+          AND n.key NOT IN ('updated_by', 'updated_at');
+
+        -- Изменение без разницы (например, повторный PUT) версию не создаёт
+        IF changes = '{}' THEN
+            RETURN NULL;
+        END IF;
+
+        IF OLD.active AND NOT NEW.active THEN
+            next_op := 'deactivate';
+        ELSIF NOT OLD.active AND NEW.active THEN
+            next_op := 'activate';
+        ELSE
+            next_op := 'update';
+        END IF;
+    ELSE
+        row_id := OLD.id;
+        next_op := 'delete';
+        -- Для удаления автор передаётся через set_config в той же транзакции
+        who := COALESCE(current_setting('geo_alert.actor', true), '');
+        row_data := to_jsonb(OLD);
+    END IF;
+
+    INSERT INTO incident_versions (incident_id, version, op, actor, snapshot, diff)
+    SELECT row_id, COALESCE(MAX(version), 0) + 1, next_op, who, row_data, changes
+    FROM incident_versions
+    WHERE incident_id = row_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER incidents_record_version
+    AFTER INSERT OR UPDATE OR DELETE ON incidents
+    FOR EACH ROW EXECUTE FUNCTION record_incident_version();
+
+CREATE OR REPLACE FUNCTION forbid_incident_version_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'incident_versions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER incident_versions_append_only
+    BEFORE UPDATE OR DELETE ON incident_versions
+    FOR EACH ROW EXECUTE FUNCTION forbid_incident_version_change();
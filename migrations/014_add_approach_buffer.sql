@@ -0,0 +1,9 @@
+-- Буфер предупреждения «приближение» вокруг зоны (метры).
+-- NULL — значение по умолчанию из APPROACH_BUFFER_M.
+ALTER TABLE incidents
+    ADD COLUMN approach_buffer_m INTEGER CHECK (approach_buffer_m >= 0);
+
+-- Строка присутствия теперь может означать и нахождение в буфере рядом
+-- с зоной (inside = FALSE); существующие строки — нахождение внутри.
+ALTER TABLE user_zone_presence
+    ADD COLUMN inside BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,2 @@
+-- Последняя проверка пользователя (оценка скорости и курса по истории).
+CREATE INDEX idx_location_checks_user_id_checked_at ON location_checks(user_id, checked_at DESC);
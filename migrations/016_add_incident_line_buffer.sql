@@ -0,0 +1,12 @@
+-- Линейные зоны: geometry типа LineString и полоса шириной line_buffer_m
+-- по обе стороны от линии (для остальных зон — 0).
+ALTER TABLE incidents
+    ADD COLUMN line_buffer_m INTEGER NOT NULL DEFAULT 0
+        CHECK (line_buffer_m >= 0);
+
+ALTER TABLE incidents
+    ADD CONSTRAINT incidents_line_buffer_check
+        CHECK ((COALESCE(geometry->>'type', '') = 'LineString') = (line_buffer_m > 0));
+
+COMMENT ON COLUMN incidents.geometry IS
+    'GeoJSON Polygon/MultiPolygon/LineString; NULL means a circle defined by lat, lon, radius_m';
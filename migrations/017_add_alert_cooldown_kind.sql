@@ -0,0 +1,8 @@
+-- Кулдаун ведётся отдельно для нахождения в зоне (alert/incident.entered)
+-- и для приближения к ней (incident.approaching): предупреждение о
+-- приближении не должно подавлять последующий вход.
+ALTER TABLE alert_cooldowns
+    ADD COLUMN kind TEXT NOT NULL DEFAULT 'inside';
+
+ALTER TABLE alert_cooldowns DROP CONSTRAINT alert_cooldowns_pkey;
+ALTER TABLE alert_cooldowns ADD PRIMARY KEY (user_id, incident_id, kind);
@@ -0,0 +1,9 @@
+-- Начало действия зоны (starts_at) каждая реплика замечает сама, а
+-- уведомления пользователям внутри неё рассылает одна — первая вставившая
+-- строку для пары (инцидент, starts_at).
+CREATE TABLE incident_start_claims (
+                                       incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+                                       starts_at TIMESTAMP NOT NULL,
+                                       claimed_at TIMESTAMP NOT NULL DEFAULT now(),
+                                       PRIMARY KEY (incident_id, starts_at)
+);
@@ -0,0 +1,13 @@
+-- Время последней учтённой проверки пользователя в отслеживании переходов.
+-- Запоздавшие проверки (из пачки или с неверно идущими часами) старше
+-- этого времени переходов не порождают, даже если у пользователя нет
+-- записей в user_zone_presence.
+CREATE TABLE user_last_seen (
+                                user_id TEXT PRIMARY KEY,
+                                seen_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO user_last_seen (user_id, seen_at)
+SELECT user_id, max(last_seen_at)
+FROM user_zone_presence
+GROUP BY user_id;
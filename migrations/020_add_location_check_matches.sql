@@ -0,0 +1,13 @@
+-- Все зоны из ответа проверки: положение точки относительно зоны
+-- (status) и расстояние до её границы в метрах. incident_ids по-прежнему
+-- содержит только зоны, внутри которых была точка.
+ALTER TABLE location_checks
+    ADD COLUMN matches JSONB NOT NULL DEFAULT '[]';
+
+-- Проверки, сохранённые раньше, знали только зоны inside
+UPDATE location_checks
+SET matches = (
+    SELECT jsonb_agg(jsonb_build_object('incident_id', id, 'status', 'inside', 'distance_m', 0))
+    FROM unnest(incident_ids) AS id
+)
+WHERE incident_ids <> '{}';
@@ -0,0 +1,78 @@
+-- Только для INCIDENT_STORE=postgis: требует расширения PostGIS и
+-- применяется после основных миграций (migrations/*.sql).
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+-- Форма зоны в виде geography (WGS-84):
+--   shape   — центр круга, полигон или линия;
+--   reach_m — радиус круга, ширина полосы линии, 0 для полигона;
+--   bounds  — shape с запасом reach_m и собственного буфера приближения
+--             зоны (+1% и 1 м), только для GiST-индекса.
+-- Точка внутри зоны, если ST_DWithin(shape, point, reach_m).
+-- Отдельная таблица не попадает в снимки истории версий (migrations/013).
+CREATE TABLE incident_areas (
+                                incident_id BIGINT PRIMARY KEY REFERENCES incidents(id) ON DELETE CASCADE,
+                                shape geography NOT NULL,
+                                reach_m DOUBLE PRECISION NOT NULL,
+                                bounds geography NOT NULL
+);
+
+CREATE INDEX idx_incident_areas_bounds ON incident_areas USING GIST (bounds);
+
+CREATE OR REPLACE FUNCTION upsert_incident_area(
+    p_id BIGINT,
+    p_geometry JSONB,
+    p_lat DOUBLE PRECISION,
+    p_lon DOUBLE PRECISION,
+    p_radius_m INTEGER,
+    p_line_buffer_m INTEGER,
+    p_approach_buffer_m INTEGER
+) RETURNS void AS $$
+DECLARE
+    area_shape geography;
+    area_reach DOUBLE PRECISION;
+    area_margin DOUBLE PRECISION;
+    area_bounds geography;
+BEGIN
+    IF p_geometry IS NULL THEN
+        area_shape := ST_SetSRID(ST_MakePoint(p_lon, p_lat), 4326)::geography;
+        area_reach := p_radius_m;
+    ELSE
+        area_shape := ST_SetSRID(ST_GeomFromGeoJSON(p_geometry::text), 4326)::geography;
+        IF p_geometry->>'type' = 'LineString' THEN
+            area_reach := p_line_buffer_m;
+        ELSE
+            area_reach := 0;
+        END IF;
+    END IF;
+
+    area_margin := area_reach + COALESCE(p_approach_buffer_m, 0);
+    IF area_margin > 0 THEN
+        area_bounds := ST_Buffer(area_shape, area_margin * 1.01 + 1);
+    ELSE
+        area_bounds := area_shape;
+    END IF;
+
+    INSERT INTO incident_areas (incident_id, shape, reach_m, bounds)
+    VALUES (p_id, area_shape, area_reach, area_bounds)
+    ON CONFLICT (incident_id) DO UPDATE
+        SET shape = EXCLUDED.shape, reach_m = EXCLUDED.reach_m, bounds = EXCLUDED.bounds;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Заполнение для уже существующих инцидентов
+SELECT upsert_incident_area(id, geometry, lat, lon, radius_m, line_buffer_m, approach_buffer_m)
+FROM incidents;
+
+CREATE OR REPLACE FUNCTION sync_incident_area() RETURNS trigger AS $$
+BEGIN
+    PERFORM upsert_incident_area(
+        NEW.id, NEW.geometry, NEW.lat, NEW.lon, NEW.radius_m, NEW.line_buffer_m, NEW.approach_buffer_m
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Удаление обрабатывает ON DELETE CASCADE
+CREATE TRIGGER incidents_sync_area
+    AFTER INSERT OR UPDATE OF geometry, lat, lon, radius_m, line_buffer_m, approach_buffer_m ON incidents
+    FOR EACH ROW EXECUTE FUNCTION sync_incident_area();
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Notification — событие NOTIFY. Reconnected=true отправляется после каждого
+// успешного LISTEN: уведомления до этого момента могли быть пропущены.
+type Notification struct {
+	Channel     string
+	Payload     string
+	Reconnected bool
+}
+
+// Listener держит отдельное соединение с LISTEN на канале и рассылает
+// уведомления подписчикам. При обрыве соединение переоткрывается.
+type Listener struct {
+	dsn     string
+	channel string
+
+	mu          sync.Mutex
+	subscribers []chan Notification
+}
+
+func NewListener(dsn, channel string) *Listener {
+	return &Listener{dsn: dsn, channel: channel}
+}
+
+// Subscribe нужно вызывать до Run.
+func (l *Listener) Subscribe() <-chan Notification {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ch := make(chan Notification, 64)
+	l.subscribers = append(l.subscribers, ch)
+	return ch
+}
+
+// Run блокируется до отмены ctx, после чего закрывает каналы подписчиков.
+func (l *Listener) Run(ctx context.Context) {
+	defer l.close()
+
+	backoff := 100 * time.Millisecond
+
+	for ctx.Err() == nil {
+		listened, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if listened {
+			backoff = 100 * time.Millisecond
+		}
+
+		log.Printf("postgres listener %q: %v, reconnecting in %s", l.channel, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
+
+// listen возвращает true, если LISTEN успел выполниться до ошибки.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return false, err
+	}
+
+	l.publish(ctx, Notification{Channel: l.channel, Reconnected: true})
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		l.publish(ctx, Notification{Channel: n.Channel, Payload: n.Payload})
+	}
+}
+
+func (l *Listener) publish(ctx context.Context, n Notification) {
+	l.mu.Lock()
+	subscribers := l.subscribers
+	l.mu.Unlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- n:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *Listener) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, ch := range l.subscribers {
+		close(ch)
+	}
+	l.subscribers = nil
+}
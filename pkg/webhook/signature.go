@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderSignature  = "X-Geo-Alert-Signature"
+	HeaderTimestamp  = "X-Geo-Alert-Timestamp"
+	HeaderDeliveryID = "X-Geo-Alert-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrMissingSignature = errors.New("missing signature headers")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStaleTimestamp   = errors.New("timestamp outside tolerance")
+)
+
+// Sign возвращает подпись HMAC-SHA256 над "<timestamp>.<body>"
+// в формате "sha256=<hex>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись и то, что timestamp (unix-секунды) отличается
+// от now не больше чем на tolerance.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(secret, ts, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
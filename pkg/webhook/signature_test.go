@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "s3cret"
+	now := time.Unix(1_800_000_000, 0)
+	body := []byte(`{"event":"alert","user_id":"truck-1"}`)
+
+	ts := now.Unix()
+	valid := Sign(secret, ts, body)
+	stamp := strconv.FormatInt(ts, 10)
+
+	cases := []struct {
+		name      string
+		signature string
+		timestamp string
+		body      []byte
+		secret    string
+		want      error
+	}{
+		{"valid", valid, stamp, body, secret, nil},
+		{"valid at tolerance edge", Sign(secret, ts-300, body), strconv.FormatInt(ts-300, 10), body, secret, nil},
+		{"tampered body", valid, stamp, []byte(`{"event":"alert","user_id":"truck-2"}`), secret, ErrInvalidSignature},
+		{"wrong secret", valid, stamp, body, "other", ErrInvalidSignature},
+		{"timestamp swapped", valid, strconv.FormatInt(ts+1, 10), body, secret, ErrInvalidSignature},
+		{"stale timestamp", Sign(secret, ts-301, body), strconv.FormatInt(ts-301, 10), body, secret, ErrStaleTimestamp},
+		{"future timestamp", Sign(secret, ts+301, body), strconv.FormatInt(ts+301, 10), body, secret, ErrStaleTimestamp},
+		{"missing signature", "", stamp, body, secret, ErrMissingSignature},
+		{"missing timestamp", valid, "", body, secret, ErrMissingSignature},
+		{"malformed timestamp", valid, "yesterday", body, secret, ErrInvalidSignature},
+		{"signature without prefix", valid[len(signaturePrefix):], stamp, body, secret, ErrInvalidSignature},
+		{"malformed signature", "sha256=zz", stamp, body, secret, ErrInvalidSignature},
+	}
+
+	for _, c := range cases {
+		err := Verify(c.secret, c.signature, c.timestamp, c.body, 5*time.Minute, now)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: Verify = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+func TestSignKnownValue(t *testing.T) {
+	// HMAC-SHA256(key="key", "1.body"), посчитан независимо
+	const want = "sha256=91b5374b153842ad05b2c4eab9349b8321b14703165bd3fb8b034dfb8be98ae5"
+	if got := Sign("key", 1, []byte("body")); got != want {
+		t.Fatalf("Sign = %q, want %q", got, want)
+	}
+}